@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jung/doc2git/internal/config"
+	"github.com/jung/doc2git/internal/health"
+	"github.com/jung/doc2git/internal/lock"
+	"github.com/jung/doc2git/internal/scheduler"
+	"github.com/jung/doc2git/internal/source"
+)
+
+// newFlagSet creates the flag set of a command. description is printed in its usage.
+func newFlagSet(name, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "Usage: docmostsaurus %s [flags]\n", name)
+		if description != "" {
+			fmt.Fprintf(out, "\n%s\n", description)
+		}
+		fmt.Fprintln(out, "\nFlags:")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args, returning the exit code and false if the command should not run.
+// Positional arguments are rejected.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected argument %q\n", flags.Arg(0))
+		flags.Usage()
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// syncFlags are the flags shared by the commands that sync
+type syncFlags struct {
+	outputDir string
+	fullSync  bool
+	recordDir string
+	replayDir string
+
+	dryRun       bool
+	dryRunReport string
+}
+
+func addSyncFlags(flags *flag.FlagSet) *syncFlags {
+	sf := &syncFlags{}
+	flags.StringVar(&sf.outputDir, "output", "", "Output directory for exported markdown files (overrides OUTPUT_DIR env)")
+	flags.BoolVar(&sf.fullSync, "full", false, "Resync every space even if it has not changed since the last sync")
+	flags.StringVar(&sf.recordDir, "record", "", "Save all Docmost API traffic of each sync run into a new bundle in this directory (overrides RECORD_DIR env)")
+	flags.StringVar(&sf.replayDir, "replay", "", "Run from a bundle recorded with -record, or the latest bundle in a -record directory, instead of Docmost (overrides REPLAY_DIR env)")
+	return sf
+}
+
+// addDryRunFlags adds the flags of a dry run, which only the sync command supports
+func addDryRunFlags(flags *flag.FlagSet, sf *syncFlags) {
+	flags.BoolVar(&sf.dryRun, "dry-run", false, "Export and convert into a scratch directory and print how the output would change, without changing it")
+	flags.StringVar(&sf.dryRunReport, "plan", "", "Also write the changes found by -dry-run to this JSON file")
+}
+
+// setupSync loads and validates the configuration with the flags applied and creates the
+// sync targets. On failure it reports the problem and returns the exit code.
+func setupSync(sf *syncFlags) (*config.Config, []*syncTarget, int) {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return nil, nil, exitConfig
+	}
+
+	// Override output directory if specified via flag
+	if sf.outputDir != "" {
+		cfg.OutputDir = sf.outputDir
+	}
+
+	// Keep sync state out of the published output unless STATE_DIR is set
+	if cfg.StateDir == "" {
+		cfg.StateDir = config.DefaultStateDir(cfg.OutputDir)
+		legacy := filepath.Join(cfg.OutputDir, ".docmostsaurus")
+		if _, err := os.Stat(legacy); err == nil {
+			log.Printf("Warning: %s is no longer used for sync state (now %s); remove it so it is not published", legacy, cfg.StateDir)
+		}
+	}
+	cfg.FullSync = sf.fullSync
+
+	if sf.recordDir != "" {
+		cfg.RecordDir = sf.recordDir
+	}
+	if sf.replayDir != "" {
+		cfg.ReplayDir = sf.replayDir
+	}
+	// A bundle holds complete space exports, so recorded and replayed runs never reuse sync state
+	if cfg.RecordDir != "" || cfg.ReplayDir != "" {
+		cfg.FullSync = true
+	}
+	cfg.DryRun = sf.dryRun || sf.dryRunReport != ""
+	cfg.DryRunReport = sf.dryRunReport
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		printConfigHelp(os.Stderr)
+		return nil, nil, exitConfig
+	}
+
+	// Create the content sources, kept across runs so Docmost sessions are reused and renewed on expiry
+	targets, err := newSyncTargets(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		return nil, nil, exitConfig
+	}
+	return cfg, targets, exitOK
+}
+
+// printConfigHelp lists the environment variables of the configuration
+func printConfigHelp(w io.Writer) {
+	fmt.Fprintln(w, "\nRequired environment variables:")
+	fmt.Fprintln(w, "  DOCMOST_BASE_URL  - Docmost server URL (e.g., http://192.168.31.101:3456)")
+	fmt.Fprintln(w, "  DOCMOST_EMAIL     - Docmost login email")
+	fmt.Fprintln(w, "  DOCMOST_PASSWORD  - Docmost login password")
+	fmt.Fprintln(w, "\n  or, instead of email and password, one of:")
+	fmt.Fprintln(w, "  DOCMOST_API_TOKEN   - Docmost API token (or DOCMOST_API_TOKEN_FILE)")
+	fmt.Fprintln(w, "  DOCMOST_AUTH_COOKIE - Existing authToken cookie value (or DOCMOST_AUTH_COOKIE_FILE)")
+	fmt.Fprintln(w, "\n  or, to convert a local directory instead of Docmost:")
+	fmt.Fprintln(w, "  SOURCE_TYPE=local - Read collections from SOURCE_DIR (default: docmost)")
+	fmt.Fprintln(w, "  SOURCE_DIR        - Directory whose subdirectories each hold a tree.json manifest and markdown files")
+	fmt.Fprintln(w, "\n  or, to sync several named sources:")
+	fmt.Fprintln(w, "  SOURCES_FILE      - JSON file listing sources with their own URL, credentials, space filters and output subdirectory")
+	fmt.Fprintln(w, "\nOptional environment variables:")
+	fmt.Fprintln(w, "  OUTPUT_DIR        - Output directory (default: ./output)")
+	fmt.Fprintln(w, "  STATE_DIR         - Sync state directory used to skip unchanged spaces (default: per OUTPUT_DIR in the user cache directory)")
+	fmt.Fprintln(w, "  SPACE_INCLUDE     - Comma-separated spaces to export (slug:<glob>, name:<glob>, id:<id> or <glob>)")
+	fmt.Fprintln(w, "  SPACE_EXCLUDE     - Comma-separated spaces never to export, same syntax as SPACE_INCLUDE")
+	fmt.Fprintln(w, "  SPACE_FILTER_FILE - File with one 'include <rule>' or 'exclude <rule>' per line")
+	fmt.Fprintln(w, "  REMOVE_EXCLUDED_SPACES - Delete existing output of excluded spaces (default: false)")
+	fmt.Fprintln(w, "  ROOT_PAGE         - Page ID or slug ID; only this page and its descendants are exported, as the top level")
+	fmt.Fprintln(w, "  RECORD_DIR        - Save all Docmost API responses and exports of each sync run into a new bundle in this directory, keeping the last 5")
+	fmt.Fprintln(w, "  REPLAY_DIR        - Run from a recorded bundle, or the latest one in a RECORD_DIR, instead of Docmost; no credentials are needed")
+	fmt.Fprintln(w, "  POSTPROCESS_STEPS   - Comma-separated post-processing steps, in order (default: the built-in pipeline)")
+	fmt.Fprintln(w, "  POSTPROCESS_DISABLE - Comma-separated post-processing steps to leave out")
+	fmt.Fprintln(w, "  POSTPROCESS_FILE    - JSON file with per-space step lists or disabled steps")
+	fmt.Fprintln(w, "  SYNC_INTERVAL     - Sync interval (e.g., 30m, 2h). If empty, run once and exit")
+	fmt.Fprintln(w, "  HTTP_PORT         - HTTP server port (default: :8080)")
+	fmt.Fprintln(w, "  SYNC_TIMEOUT      - Maximum duration of a single sync run (e.g., 45m). If empty, no limit")
+	fmt.Fprintln(w, "  SYNC_CONCURRENCY  - Number of spaces exported and processed in parallel (default: 1)")
+	fmt.Fprintln(w, "  SYNC_REPORT_RUNS  - Runs kept in the _sync-report.json of each space; 0 disables the report (default: 10)")
+	fmt.Fprintln(w, "  METADATA_CONCURRENCY - Parallel child page requests per space when building _metadata.json (default: 4)")
+	fmt.Fprintln(w, "  DOCMOST_TIMEOUT          - Time limit of a single Docmost request, including downloads (default: 120s, 0 disables)")
+	fmt.Fprintln(w, "  DOCMOST_CA_FILE          - PEM CA bundle trusted in addition to the system roots")
+	fmt.Fprintln(w, "  DOCMOST_CLIENT_CERT_FILE - PEM client certificate for mutual TLS (with DOCMOST_CLIENT_KEY_FILE)")
+	fmt.Fprintln(w, "  DOCMOST_CLIENT_KEY_FILE  - PEM private key of the client certificate")
+	fmt.Fprintln(w, "  DOCMOST_PROXY_URL        - HTTP(S) or SOCKS5 proxy for Docmost (default: HTTP_PROXY/HTTPS_PROXY/NO_PROXY)")
+	fmt.Fprintln(w, "  DOCMOST_SKIP_VERSION_CHECK - Sync even if Docmost is older than the oldest supported release (default: false)")
+	fmt.Fprintln(w, "  DOCMOST_MAX_RETRIES      - Retries for transient Docmost errors (default: 3)")
+	fmt.Fprintln(w, "  DOCMOST_RETRY_BASE_DELAY - Initial retry delay, doubled per retry (default: 1s)")
+	fmt.Fprintln(w, "  DOCMOST_RETRY_MAX_DELAY  - Maximum retry delay (default: 30s)")
+	fmt.Fprintln(w, "  DOCMOST_RATE_LIMIT       - Maximum Docmost requests per second (default: 0, unlimited)")
+	fmt.Fprintln(w, "  ZIP_MAX_TOTAL_SIZE_MB     - Maximum uncompressed size of a space export (default: 10240)")
+	fmt.Fprintln(w, "  ZIP_MAX_FILES             - Maximum number of files in a space export (default: 100000)")
+	fmt.Fprintln(w, "  ZIP_MAX_COMPRESSION_RATIO - Maximum uncompressed/compressed size ratio (default: 200)")
+}
+
+// logStartup logs the configuration a sync or serve process runs with
+func logStartup(cfg *config.Config, targets []*syncTarget) {
+	log.Println("=== Docmost Markdown Exporter ===")
+	for _, t := range targets {
+		t.logSummary()
+	}
+	if cfg.RecordDir != "" {
+		log.Printf("Recording API traffic to: %s", cfg.RecordDir)
+	}
+	if cfg.ReplayDir != "" {
+		log.Printf("Replaying API traffic from: %s", cfg.ReplayDir)
+	}
+	if cfg.FullSync {
+		log.Println("Full sync: saved sync state is ignored")
+	}
+	if cfg.DryRun {
+		log.Println("Dry run: the output and sync state are not changed")
+	}
+}
+
+func runSyncCommand(args []string) int {
+	flags := newFlagSet("sync", "Exports, converts and swaps in every space of every source once, then exits.")
+	sf := addSyncFlags(flags)
+	addDryRunFlags(flags, sf)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, targets, code := setupSync(sf)
+	if code != exitOK {
+		return code
+	}
+	return syncOnce(cfg, targets)
+}
+
+// syncOnce syncs all targets once, bounded by SYNC_TIMEOUT and stopped by SIGINT or SIGTERM
+func syncOnce(cfg *config.Config, targets []*syncTarget) int {
+	// Acquire file lock to prevent concurrent instances
+	fileLock := lock.NewFileLock()
+	if err := fileLock.TryLock(); err != nil {
+		log.Printf("Failed to acquire lock: %v", err)
+		return exitLocked
+	}
+	defer fileLock.Unlock()
+
+	logStartup(cfg, targets)
+	log.Println("Mode: One-shot (run once and exit)")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if cfg.SyncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.SyncTimeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	if err := runTargets(ctx, targets, health.NewChecker(0)); err != nil {
+		log.Printf("Sync failed: %v (duration: %v)", err, time.Since(startTime))
+		if ctx.Err() != nil {
+			return exitFailed
+		}
+		return syncExitCode(err, len(targets))
+	}
+	log.Printf("Sync completed successfully (duration: %v)", time.Since(startTime))
+	return exitOK
+}
+
+// syncExitCode returns the exit code for the error of runTargets on n targets:
+// exitUnavailable if every target failed to list its spaces because Docmost could not
+// be reached or rejected the credentials, exitFailed otherwise. A target whose spaces
+// were listed counts as failed even if all of its space syncs hit network errors.
+func syncExitCode(err error, n int) int {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	if len(errs) < n {
+		return exitFailed
+	}
+	for _, err := range errs {
+		var listErr *collectionsError
+		if !errors.As(err, &listErr) {
+			return exitFailed
+		}
+		if kind := health.ClassifyError(listErr.err); kind != health.ErrorKindNetwork && kind != health.ErrorKindAuth {
+			return exitFailed
+		}
+	}
+	return exitUnavailable
+}
+
+func runServeCommand(args []string) int {
+	flags := newFlagSet("serve", "Syncs every source every SYNC_INTERVAL and serves the health API until SIGINT or SIGTERM.")
+	sf := addSyncFlags(flags)
+	interval := flags.Duration("interval", 0, "Sync interval (overrides SYNC_INTERVAL env)")
+	port := flags.String("port", "", "HTTP listen address of the health API, e.g. :8080 (overrides HTTP_PORT env)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	cfg, targets, code := setupSync(sf)
+	if code != exitOK {
+		return code
+	}
+	if *interval > 0 {
+		cfg.SyncInterval = *interval
+	}
+	if *port != "" {
+		cfg.HTTPPort = *port
+	}
+	if cfg.SyncInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "Configuration error: serve needs SYNC_INTERVAL or -interval; use 'docmostsaurus sync' to sync once")
+		return exitConfig
+	}
+	return serve(cfg, targets)
+}
+
+// serve runs the scheduler and the health API until shutdown. With a SyncInterval of 0
+// it syncs once, as earlier releases did without a command.
+func serve(cfg *config.Config, targets []*syncTarget) int {
+	// Acquire file lock to prevent concurrent instances
+	fileLock := lock.NewFileLock()
+	if err := fileLock.TryLock(); err != nil {
+		log.Printf("Failed to acquire lock: %v", err)
+		return exitLocked
+	}
+	defer fileLock.Unlock()
+
+	logStartup(cfg, targets)
+	if cfg.SyncInterval > 0 {
+		log.Printf("Sync Interval: %v", cfg.SyncInterval)
+	} else {
+		log.Println("Mode: One-shot (run once and exit)")
+	}
+
+	// Start HTTP server (health check + future API endpoints)
+	healthChecker := health.NewChecker(cfg.SyncInterval)
+	healthServer := health.NewServer(healthChecker, cfg.HTTPPort)
+	healthServer.Start()
+	log.Printf("HTTP server started on %s", cfg.HTTPPort)
+	defer healthServer.Stop()
+
+	// Create scheduler with sync function
+	var lastErr error
+	sched := scheduler.NewScheduler(cfg, func(ctx context.Context, cfg *config.Config) error {
+		healthChecker.SetRunning(true)
+		defer healthChecker.SetRunning(false)
+
+		err := runTargets(ctx, targets, healthChecker)
+		healthChecker.UpdateSyncStatus(err)
+		lastErr = err
+		return err
+	})
+
+	// Start scheduler (blocks until shutdown)
+	sched.Start()
+
+	log.Println("=== Shutdown Complete ===")
+	if cfg.SyncInterval <= 0 && lastErr != nil {
+		return exitFailed
+	}
+	return exitOK
+}
+
+func runStatusCommand(args []string) int {
+	flags := newFlagSet("status", "Queries the health API of a running serve process. Exits with 1 if it is degraded or unhealthy.")
+	url := flags.String("url", "", "Base URL of the health API (default: http://localhost<HTTP_PORT>)")
+	asJSON := flags.Bool("json", false, "Print the health response as JSON")
+	timeout := flags.Duration("timeout", 5*time.Second, "Time limit of the request")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	baseURL := *url
+	if baseURL == "" {
+		baseURL = healthURL(os.Getenv("HTTP_PORT"))
+	}
+	client := &http.Client{Timeout: *timeout}
+	resp, err := client.Get(strings.TrimSuffix(baseURL, "/") + "/health")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reach docmostsaurus at %s: %v\n", baseURL, err)
+		return exitUnavailable
+	}
+	defer resp.Body.Close()
+
+	// The health API answers 200, or 503 while unhealthy; anything else is not docmostsaurus
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		fmt.Fprintf(os.Stderr, "Unexpected health response from %s: HTTP %d\n", baseURL, resp.StatusCode)
+		return exitUnavailable
+	}
+
+	var status health.Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid health response from %s (HTTP %d): %v\n", baseURL, resp.StatusCode, err)
+		return exitUnavailable
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(status)
+	} else {
+		printStatus(os.Stdout, status)
+	}
+
+	if status.Status != "healthy" {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// healthURL returns the local URL of a health API listening on addr, an HTTP_PORT value
+func healthURL(addr string) string {
+	if addr == "" {
+		addr = ":8080"
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return "http://" + addr
+}
+
+// printStatus prints a health status for people
+func printStatus(w io.Writer, status health.Status) {
+	fmt.Fprintf(w, "Status:     %s\n", status.Status)
+	fmt.Fprintf(w, "Running:    %t\n", status.IsRunning)
+	fmt.Fprintf(w, "Uptime:     %s\n", status.Uptime)
+	fmt.Fprintf(w, "Syncs:      %d\n", status.SyncCount)
+	if !status.LastSync.IsZero() {
+		fmt.Fprintf(w, "Last sync:  %s\n", status.LastSync.Format(time.RFC3339))
+	}
+	if status.NextSync != "" {
+		fmt.Fprintf(w, "Next sync:  in %s\n", status.NextSync)
+	}
+	if status.LastError != "" {
+		fmt.Fprintf(w, "Last error: [%s] %s\n", status.ErrorKind, status.LastError)
+	}
+
+	names := make([]string, 0, len(status.Sources))
+	for name := range status.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		fmt.Fprintln(w, "Sources:")
+	}
+	for _, name := range names {
+		s := status.Sources[name]
+		fmt.Fprintf(w, "  %-20s %-9s", name, s.Status)
+		if s.LastError != "" {
+			fmt.Fprintf(w, " [%s] %s", s.ErrorKind, s.LastError)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func runCheckCommand(args []string) int {
+	flags := newFlagSet("check", "Validates the configuration and connects to every source, listing the spaces that would be synced.")
+	sf := addSyncFlags(flags)
+	offline := flags.Bool("offline", false, "Only validate the configuration, without contacting any source")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	_, targets, code := setupSync(sf)
+	if code != exitOK {
+		return code
+	}
+	fmt.Printf("Configuration OK (%d source(s))\n", len(targets))
+	if *offline {
+		return exitOK
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	code = exitOK
+	for _, t := range targets {
+		if err := checkTarget(ctx, t); err != nil {
+			fmt.Printf("%s: FAILED: %v\n", t.label(), err)
+			code = exitUnavailable
+		}
+	}
+	return code
+}
+
+// checkTarget connects to the source of t and prints what it would sync
+func checkTarget(ctx context.Context, t *syncTarget) error {
+	collections, err := t.src.Collections(ctx)
+	if err != nil {
+		return err
+	}
+	selected, _ := t.filter.Apply(collections)
+
+	detail := t.src.Kind()
+	if d, ok := t.src.(*source.Docmost); ok {
+		if caps := d.Client().Capabilities(); caps != nil && caps.Version != "" {
+			detail += " " + caps.Version
+		}
+	}
+	fmt.Printf("%s: OK (%s), %d spaces, %d selected\n", t.label(), detail, len(collections), len(selected))
+	for _, space := range selected {
+		fmt.Printf("  %s (%s)\n", space.Name, space.Slug)
+	}
+	return nil
+}
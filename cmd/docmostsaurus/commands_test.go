@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/fakedocmost"
+	"github.com/jung/doc2git/internal/health"
+)
+
+// TestCheckCommand_ExitCodes tests the exit codes of check for a reachable server, a bad flag,
+// rejected credentials, an offline check and an invalid configuration
+func TestCheckCommand_ExitCodes(t *testing.T) {
+	server, _ := fakedocmost.NewTestServer(t, "../../internal/fakedocmost/testdata")
+	t.Setenv("DOCMOST_BASE_URL", server.URL)
+	t.Setenv("DOCMOST_EMAIL", fakedocmost.DefaultEmail)
+	t.Setenv("DOCMOST_PASSWORD", fakedocmost.DefaultPassword)
+	t.Setenv("OUTPUT_DIR", t.TempDir())
+	t.Setenv("STATE_DIR", t.TempDir())
+
+	if code := runCheckCommand(nil); code != exitOK {
+		t.Errorf("check: expected exit code %d, got %d", exitOK, code)
+	}
+	if code := runCheckCommand([]string{"-unknown-flag"}); code != exitUsage {
+		t.Errorf("check with an unknown flag: expected exit code %d, got %d", exitUsage, code)
+	}
+
+	t.Setenv("DOCMOST_PASSWORD", "wrong")
+	if code := runCheckCommand(nil); code != exitUnavailable {
+		t.Errorf("check with a wrong password: expected exit code %d, got %d", exitUnavailable, code)
+	}
+	if code := runCheckCommand([]string{"-offline"}); code != exitOK {
+		t.Errorf("offline check: expected exit code %d, got %d", exitOK, code)
+	}
+
+	t.Setenv("POSTPROCESS_STEPS", "no-such-step")
+	if code := runCheckCommand([]string{"-offline"}); code != exitConfig {
+		t.Errorf("check with an unknown step: expected exit code %d, got %d", exitConfig, code)
+	}
+}
+
+// TestCheckCommand_DoesNotRecord tests that check leaves RECORD_DIR alone, so it cannot
+// push a recorded sync out of the kept bundles
+func TestCheckCommand_DoesNotRecord(t *testing.T) {
+	server, _ := fakedocmost.NewTestServer(t, "../../internal/fakedocmost/testdata")
+	recordDir := filepath.Join(t.TempDir(), "bundles")
+	t.Setenv("DOCMOST_BASE_URL", server.URL)
+	t.Setenv("DOCMOST_EMAIL", fakedocmost.DefaultEmail)
+	t.Setenv("DOCMOST_PASSWORD", fakedocmost.DefaultPassword)
+	t.Setenv("OUTPUT_DIR", t.TempDir())
+	t.Setenv("STATE_DIR", t.TempDir())
+	t.Setenv("RECORD_DIR", recordDir)
+
+	if code := runCheckCommand(nil); code != exitOK {
+		t.Fatalf("check: expected exit code %d, got %d", exitOK, code)
+	}
+	if _, err := os.Stat(recordDir); !os.IsNotExist(err) {
+		t.Errorf("check wrote to RECORD_DIR")
+	}
+
+	if code := runSyncCommand(nil); code != exitOK {
+		t.Fatalf("sync: expected exit code %d, got %d", exitOK, code)
+	}
+	if bundles, _ := filepath.Glob(filepath.Join(recordDir, "*", "bundle.json")); len(bundles) != 1 {
+		t.Errorf("expected the sync to record 1 bundle, got %v", bundles)
+	}
+}
+
+// TestSyncCommand_ExitCodes tests that a sync failing only because Docmost rejects the
+// login exits as unavailable, and that other failures exit as failed
+func TestSyncCommand_ExitCodes(t *testing.T) {
+	server, fake := fakedocmost.NewTestServer(t, "../../internal/fakedocmost/testdata")
+	t.Setenv("DOCMOST_BASE_URL", server.URL)
+	t.Setenv("DOCMOST_EMAIL", fakedocmost.DefaultEmail)
+	t.Setenv("DOCMOST_PASSWORD", fakedocmost.DefaultPassword)
+	t.Setenv("OUTPUT_DIR", t.TempDir())
+	t.Setenv("STATE_DIR", t.TempDir())
+
+	if code := runSyncCommand(nil); code != exitOK {
+		t.Errorf("sync: expected exit code %d, got %d", exitOK, code)
+	}
+
+	// One space times out while the others sync: a partial failure, not an unreachable server
+	t.Setenv("DOCMOST_TIMEOUT", "200ms")
+	t.Setenv("DOCMOST_MAX_RETRIES", "0")
+	fake.Inject(fakedocmost.Fault{Path: "/api/spaces/export", Delay: time.Second, Times: 1})
+	if code := runSyncCommand([]string{"-full"}); code != exitFailed {
+		t.Errorf("sync with one space timing out: expected exit code %d, got %d", exitFailed, code)
+	}
+	if n := fake.Requests("/api/spaces/export"); n < 2 {
+		t.Errorf("expected the other spaces to be exported, got %d exports", n)
+	}
+
+	t.Setenv("DOCMOST_PASSWORD", "wrong")
+	if code := runSyncCommand(nil); code != exitUnavailable {
+		t.Errorf("sync with a wrong password: expected exit code %d, got %d", exitUnavailable, code)
+	}
+
+	authErr := fmt.Errorf("source a: %w", &collectionsError{err: &docmost.AuthError{Op: "login", StatusCode: 401}})
+	mixed := errors.Join(authErr, errors.New("source b: export failed"))
+	if code := syncExitCode(mixed, 2); code != exitFailed {
+		t.Errorf("auth and sync failures: expected exit code %d, got %d", exitFailed, code)
+	}
+	if code := syncExitCode(errors.Join(authErr), 2); code != exitFailed {
+		t.Errorf("one of two sources unavailable: expected exit code %d, got %d", exitFailed, code)
+	}
+	spaceAuthErr := fmt.Errorf("1 of 2 spaces failed: %w", errors.Join(fmt.Errorf("space a: %w", &docmost.AuthError{Op: "export", StatusCode: 403})))
+	if code := syncExitCode(errors.Join(spaceAuthErr), 1); code != exitFailed {
+		t.Errorf("space rejected after listing: expected exit code %d, got %d", exitFailed, code)
+	}
+}
+
+// TestStatusCommand_ExitCodes tests the exit codes of status for a healthy, a degraded and a stopped daemon
+func TestStatusCommand_ExitCodes(t *testing.T) {
+	status := health.Status{Status: "healthy"}
+	code := http.StatusOK
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(status)
+	}))
+	defer daemon.Close()
+
+	if code := runStatusCommand([]string{"-url", daemon.URL}); code != exitOK {
+		t.Errorf("healthy daemon: expected exit code %d, got %d", exitOK, code)
+	}
+	status = health.Status{Status: "degraded", LastError: "space x: export failed"}
+	if code := runStatusCommand([]string{"-url", daemon.URL, "-json"}); code != exitFailed {
+		t.Errorf("degraded daemon: expected exit code %d, got %d", exitFailed, code)
+	}
+
+	status = health.Status{Status: "unhealthy"}
+	code = http.StatusServiceUnavailable
+	if code := runStatusCommand([]string{"-url", daemon.URL}); code != exitFailed {
+		t.Errorf("unhealthy daemon: expected exit code %d, got %d", exitFailed, code)
+	}
+
+	// A proxy or another service answering with an error, even with a JSON body
+	status = health.Status{Status: "healthy"}
+	code = http.StatusBadGateway
+	if code := runStatusCommand([]string{"-url", daemon.URL}); code != exitUnavailable {
+		t.Errorf("HTTP 502: expected exit code %d, got %d", exitUnavailable, code)
+	}
+
+	url := daemon.URL
+	daemon.Close()
+	if code := runStatusCommand([]string{"-url", url}); code != exitUnavailable {
+		t.Errorf("stopped daemon: expected exit code %d, got %d", exitUnavailable, code)
+	}
+}
+
+// TestHealthURL tests the health API URL derived from an HTTP listen address
+func TestHealthURL(t *testing.T) {
+	tests := map[string]string{
+		"":               "http://localhost:8080",
+		":9000":          "http://localhost:9000",
+		"127.0.0.1:8081": "http://127.0.0.1:8081",
+	}
+	for addr, want := range tests {
+		if got := healthURL(addr); got != want {
+			t.Errorf("healthURL(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
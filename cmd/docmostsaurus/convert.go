@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jung/doc2git/internal/config"
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/fsutil"
+	"github.com/jung/doc2git/internal/postprocess"
+)
+
+// convertOptions describes an offline conversion of an existing space export
+type convertOptions struct {
+	input    string // export ZIP or extracted export directory
+	output   string // space directory to write
+	metadata string // _metadata.json to use instead of the one in the input
+	name     string // space name if the metadata has to be inferred
+	force    bool   // replace an existing output directory
+}
+
+// runConvert implements the convert command: it converts a Docmost export ZIP or an
+// extracted export directory to the Docusaurus format without contacting Docmost
+func runConvert(args []string) int {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintln(out, "Usage: docmostsaurus convert [flags] <export.zip | export-dir>")
+		fmt.Fprintln(out, "\nConverts a Docmost space export to the Docusaurus format without contacting Docmost.")
+		fmt.Fprintln(out, "The page tree is read from -metadata or the _metadata.json of the input; without either,")
+		fmt.Fprintln(out, "it is inferred from the file layout.")
+		fmt.Fprintln(out, "\nFlags:")
+		flags.PrintDefaults()
+	}
+	var opts convertOptions
+	flags.StringVar(&opts.output, "output", "", "Directory to write the converted space to (required)")
+	flags.StringVar(&opts.metadata, "metadata", "", "_metadata.json of the space, if the input has none")
+	flags.StringVar(&opts.name, "name", "", "Space name used when the metadata is inferred (default: input file name)")
+	flags.BoolVar(&opts.force, "force", false, "Replace the output directory if it already exists")
+	steps := flags.String("steps", "", "Comma-separated post-processing steps (overrides POSTPROCESS_STEPS env)")
+	disable := flags.String("disable", "", "Comma-separated post-processing steps to leave out (overrides POSTPROCESS_DISABLE env)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() != 1 || opts.output == "" {
+		flags.Usage()
+		return exitUsage
+	}
+	opts.input = flags.Arg(0)
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return exitConfig
+	}
+	if *steps != "" {
+		cfg.PostprocessSteps = splitFlagList(*steps)
+	}
+	if *disable != "" {
+		cfg.PostprocessDisable = splitFlagList(*disable)
+	}
+	if _, err := newPipelineSet(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		return exitConfig
+	}
+
+	report, err := convertExport(cfg, opts)
+	if err != nil {
+		log.Printf("Conversion failed: %v", err)
+		return exitFailed
+	}
+	log.Printf("Converted %s to %s in %s (%d warnings)", opts.input, opts.output, report.Duration, len(report.Warnings()))
+	return exitOK
+}
+
+// convertExport converts the export described by opts into opts.output. The output is
+// built next to it and swapped in only once the pipeline has run; the input is not modified.
+func convertExport(cfg *config.Config, opts convertOptions) (*postprocess.Report, error) {
+	info, err := os.Stat(opts.input)
+	if err != nil {
+		return nil, err
+	}
+	if entries, err := os.ReadDir(opts.output); err == nil && len(entries) > 0 && !opts.force {
+		return nil, fmt.Errorf("output directory %s is not empty, use -force to replace it", opts.output)
+	}
+
+	pipelines, err := newPipelineSet(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	output := filepath.Clean(opts.output)
+	tempDir := output + "_temp"
+	cleanupTempDir(tempDir)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating temp directory %s: %w", tempDir, err)
+	}
+
+	meta, err := prepareExport(cfg, opts, info.IsDir(), tempDir)
+	if err != nil {
+		cleanupTempDir(tempDir)
+		return nil, err
+	}
+
+	space := docmost.Space{ID: meta.ID, Name: meta.Name, Slug: meta.Slug}
+	report := postProcessSpace(tempDir, meta.Name, pipelines.forSpace(space))
+
+	if err := atomicSwap(output, tempDir, output+"_old"); err != nil {
+		cleanupTempDir(tempDir)
+		return nil, fmt.Errorf("error during atomic swap: %w", err)
+	}
+	return report, nil
+}
+
+// prepareExport extracts or copies the export into dir and writes its _metadata.json
+func prepareExport(cfg *config.Config, opts convertOptions, isDir bool, dir string) (*docmost.SpaceMeta, error) {
+	var files []string
+	var err error
+	if isDir {
+		log.Printf("Copying export directory %s...", opts.input)
+		files, err = copyExportDir(opts.input, dir)
+	} else {
+		log.Printf("Extracting export archive %s...", opts.input)
+		files, err = docmost.ExtractArchive(opts.input, dir, docmost.ExtractLimits{
+			MaxTotalSize:        cfg.ZipMaxTotalSize,
+			MaxFiles:            cfg.ZipMaxFiles,
+			MaxCompressionRatio: cfg.ZipMaxCompressionRatio,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metaPath := filepath.Join(dir, "_metadata.json")
+	if opts.metadata != "" {
+		metaPath = opts.metadata
+	}
+
+	var meta *docmost.SpaceMeta
+	data, err := os.ReadFile(metaPath)
+	switch {
+	case err == nil:
+		meta = &docmost.SpaceMeta{}
+		if err := json.Unmarshal(data, meta); err != nil {
+			return nil, fmt.Errorf("invalid metadata %s: %w", metaPath, err)
+		}
+		if !hasFilePaths(meta.Pages) {
+			if issues := docmost.AssignFilePaths(meta, files); len(issues) > 0 {
+				log.Printf("Warning: %d pages of '%s' could not be matched to a file unambiguously, see fileIssues in _metadata.json",
+					len(issues), meta.Name)
+			}
+		}
+	case errors.Is(err, fs.ErrNotExist) && opts.metadata == "":
+		name := opts.name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(filepath.Clean(opts.input)), ".zip")
+		}
+		log.Printf("No _metadata.json found, inferring the page tree of '%s' from the files", name)
+		meta = docmost.InferSpaceMeta(name, files)
+	default:
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	data, err = json.MarshalIndent(meta, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "_metadata.json"), data, 0644)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error writing metadata file: %w", err)
+	}
+	return meta, nil
+}
+
+// splitFlagList splits a comma-separated flag value, dropping empty items
+func splitFlagList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// hasFilePaths reports whether any page of the tree has its file assigned
+func hasFilePaths(pages []*docmost.PageMeta) bool {
+	for _, pm := range pages {
+		if pm.FilePath != "" || hasFilePaths(pm.Children) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyExportDir copies the regular files below src into dst and returns their
+// "/"-separated paths relative to dst, like an extracted export
+func copyExportDir(src, dst string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if err := fsutil.CopyFile(path, filepath.Join(dst, rel)); err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy export directory: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung/doc2git/internal/config"
+	"github.com/jung/doc2git/internal/docmost"
+)
+
+// writeExportZip writes files, by "/"-separated path, into a ZIP like a downloaded space export
+func writeExportZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to write zip: %v", err)
+	}
+}
+
+// TestConvertExport_ZipWithoutMetadata tests converting an export ZIP without _metadata.json,
+// whose page tree is inferred from the file layout
+func TestConvertExport_ZipWithoutMetadata(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "handbook.zip")
+	writeExportZip(t, input, map[string]string{
+		"Guide.md":               "# Guide\n",
+		"Guide/Install.md":       "Run {command} to install.\n",
+		"Guide/files/a/logo.png": "png",
+	})
+	output := filepath.Join(dir, "out")
+
+	report, err := convertExport(&config.Config{}, convertOptions{input: input, output: output})
+	if err != nil {
+		t.Fatalf("convertExport failed: %v", err)
+	}
+	if len(report.Steps) == 0 {
+		t.Error("expected a report of the pipeline steps")
+	}
+
+	data, err := os.ReadFile(filepath.Join(output, "_metadata.json"))
+	if err != nil {
+		t.Fatalf("missing _metadata.json: %v", err)
+	}
+	var meta docmost.SpaceMeta
+	json.Unmarshal(data, &meta)
+	if meta.Name != "handbook" || meta.TotalPages != 2 {
+		t.Errorf("expected 2 inferred pages of space handbook, got %q with %d", meta.Name, meta.TotalPages)
+	}
+
+	tree := readTree(t, output)
+	var install string
+	for path, content := range tree {
+		if strings.HasSuffix(path, "Install.md") {
+			install = content
+		}
+	}
+	if !strings.Contains(install, "`{command}`") || !strings.Contains(install, "title: Install") {
+		t.Errorf("expected a converted Install page, got tree %v", tree)
+	}
+	if _, err := os.Stat(output + "_temp"); !os.IsNotExist(err) {
+		t.Error("temp directory was left behind")
+	}
+
+	// An existing output is only replaced with force
+	if _, err := convertExport(&config.Config{}, convertOptions{input: input, output: output}); err == nil {
+		t.Error("expected an error for a non-empty output directory")
+	}
+	if _, err := convertExport(&config.Config{}, convertOptions{input: input, output: output, force: true}); err != nil {
+		t.Errorf("convertExport with force failed: %v", err)
+	}
+}
+
+// TestConvertExport_DirectoryWithMetadata tests converting an export directory with its _metadata.json
+// through the configured post-processing steps
+func TestConvertExport_DirectoryWithMetadata(t *testing.T) {
+	input := t.TempDir()
+	os.WriteFile(filepath.Join(input, "Welcome.md"), []byte("# Welcome\n"), 0644)
+	os.WriteFile(filepath.Join(input, "untitled.md"), []byte("# untitled\n"), 0644)
+	meta := docmost.SpaceMeta{ID: "s1", Name: "General", Slug: "general", Pages: []*docmost.PageMeta{
+		{ID: "p1", Title: "Welcome", Position: "a0"},
+		{ID: "p2", Title: "untitled", Position: "a1"},
+	}}
+	data, _ := json.Marshal(meta)
+	os.WriteFile(filepath.Join(input, "_metadata.json"), data, 0644)
+
+	output := filepath.Join(t.TempDir(), "general")
+	cfg := &config.Config{PostprocessSteps: []string{"remove-untitled-files", "romanize"}}
+	if _, err := convertExport(cfg, convertOptions{input: input, output: output}); err != nil {
+		t.Fatalf("convertExport failed: %v", err)
+	}
+
+	tree := readTree(t, output)
+	if _, ok := tree["untitled.md"]; ok {
+		t.Error("expected the untitled placeholder to be removed")
+	}
+	if !strings.Contains(tree["Welcome.md"], "sidebar_position: 1") {
+		t.Errorf("expected frontmatter from the given metadata, got %q", tree["Welcome.md"])
+	}
+	if _, err := os.Stat(filepath.Join(input, "untitled.md")); err != nil {
+		t.Error("the input directory was modified")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// syncPlan lists the changes a dry run found, without any of them having been made
+type syncPlan struct {
+	OutputDir   string      `json:"outputDir"`
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Spaces      []spacePlan `json:"spaces"`
+
+	// RemovedSpaces are output directories of excluded spaces that REMOVE_EXCLUDED_SPACES would delete
+	RemovedSpaces []string `json:"removedSpaces,omitempty"`
+}
+
+// spacePlan lists the file changes a sync would make to the output directory of one space.
+// Paths are relative to the space directory.
+type spacePlan struct {
+	Space     string       `json:"space"`
+	SpaceID   string       `json:"spaceId"`
+	Dir       string       `json:"dir"`
+	Unchanged bool         `json:"unchanged,omitempty"` // skipped because nothing changed since the last sync
+	Error     string       `json:"error,omitempty"`
+	Added     []string     `json:"added"`
+	Removed   []string     `json:"removed"`
+	Renamed   []fileRename `json:"renamed"`
+	Modified  []string     `json:"modified"`
+}
+
+// fileRename is a file moved without changing its content
+type fileRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// diffDirs compares the files below oldDir and newDir. A file removed from oldDir whose
+// content reappears under a new path in newDir is reported as renamed. A missing oldDir
+// counts as empty, and the sync report, which a dry run does not write, is left out.
+func diffDirs(oldDir, newDir string) (*spacePlan, error) {
+	oldFiles, err := hashFiles(oldDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	delete(oldFiles, syncReportFile)
+	newFiles, err := hashFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &spacePlan{Added: []string{}, Removed: []string{}, Renamed: []fileRename{}, Modified: []string{}}
+	removedByHash := make(map[string][]string)
+	for path, hash := range oldFiles {
+		if _, ok := newFiles[path]; !ok {
+			removedByHash[hash] = append(removedByHash[hash], path)
+		}
+	}
+	for _, paths := range removedByHash {
+		sort.Strings(paths)
+	}
+
+	added := make([]string, 0)
+	for path, hash := range newFiles {
+		oldHash, existed := oldFiles[path]
+		switch {
+		case !existed:
+			added = append(added, path)
+		case oldHash != hash:
+			plan.Modified = append(plan.Modified, path)
+		}
+	}
+	sort.Strings(added)
+
+	for _, path := range added {
+		hash := newFiles[path]
+		if from := removedByHash[hash]; len(from) > 0 {
+			plan.Renamed = append(plan.Renamed, fileRename{From: from[0], To: path})
+			removedByHash[hash] = from[1:]
+			continue
+		}
+		plan.Added = append(plan.Added, path)
+	}
+	for _, paths := range removedByHash {
+		plan.Removed = append(plan.Removed, paths...)
+	}
+
+	sort.Strings(plan.Removed)
+	sort.Strings(plan.Modified)
+	sort.Slice(plan.Renamed, func(i, j int) bool { return plan.Renamed[i].From < plan.Renamed[j].From })
+	return plan, nil
+}
+
+// hashFiles returns the SHA-256 of every regular file below dir, by "/"-separated relative path
+func hashFiles(dir string) (map[string]string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	return files, err
+}
+
+// print writes the plan for people, one line per changed file
+func (p *syncPlan) print(w io.Writer) {
+	fmt.Fprintf(w, "Dry run: nothing was changed in %s\n", p.OutputDir)
+	for _, sp := range p.Spaces {
+		switch {
+		case sp.Error != "":
+			fmt.Fprintf(w, "\nSpace '%s': failed: %s\n", sp.Space, sp.Error)
+			continue
+		case sp.Unchanged:
+			fmt.Fprintf(w, "\nSpace '%s': unchanged since the last sync\n", sp.Space)
+			continue
+		}
+
+		fmt.Fprintf(w, "\nSpace '%s' (%s): %d added, %d removed, %d renamed, %d modified\n",
+			sp.Space, sp.Dir, len(sp.Added), len(sp.Removed), len(sp.Renamed), len(sp.Modified))
+		for _, path := range sp.Added {
+			fmt.Fprintf(w, "  + %s\n", path)
+		}
+		for _, path := range sp.Removed {
+			fmt.Fprintf(w, "  - %s\n", path)
+		}
+		for _, r := range sp.Renamed {
+			fmt.Fprintf(w, "  R %s -> %s\n", r.From, r.To)
+		}
+		for _, path := range sp.Modified {
+			fmt.Fprintf(w, "  M %s\n", path)
+		}
+	}
+	for _, dir := range p.RemovedSpaces {
+		fmt.Fprintf(w, "\nExcluded space directory would be removed: %s\n", dir)
+	}
+}
+
+// write saves the plan as JSON to path
+func (p *syncPlan) write(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
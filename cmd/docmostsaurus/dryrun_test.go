@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jung/doc2git/internal/docmost"
+)
+
+// TestDiffDirs tests that diffDirs reports added, removed, renamed and modified files
+func TestDiffDirs(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(oldDir, "same.md", "same")
+	write(oldDir, "edited.md", "before")
+	write(oldDir, "gone.md", "gone")
+	write(oldDir, "guide/한글.md", "moved")
+	write(newDir, "same.md", "same")
+	write(newDir, "edited.md", "after")
+	write(newDir, "guide/hangeul.md", "moved")
+	write(newDir, "new/page.md", "new")
+
+	plan, err := diffDirs(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("diffDirs failed: %v", err)
+	}
+	if want := []string{"new/page.md"}; !reflect.DeepEqual(plan.Added, want) {
+		t.Errorf("added = %v, want %v", plan.Added, want)
+	}
+	if want := []string{"gone.md"}; !reflect.DeepEqual(plan.Removed, want) {
+		t.Errorf("removed = %v, want %v", plan.Removed, want)
+	}
+	if want := []fileRename{{From: "guide/한글.md", To: "guide/hangeul.md"}}; !reflect.DeepEqual(plan.Renamed, want) {
+		t.Errorf("renamed = %v, want %v", plan.Renamed, want)
+	}
+	if want := []string{"edited.md"}; !reflect.DeepEqual(plan.Modified, want) {
+		t.Errorf("modified = %v, want %v", plan.Modified, want)
+	}
+
+	// Without a previous output everything is added
+	plan, err = diffDirs(filepath.Join(oldDir, "missing"), newDir)
+	if err != nil {
+		t.Fatalf("diffDirs without old directory failed: %v", err)
+	}
+	if len(plan.Added) != 4 || len(plan.Removed) != 0 {
+		t.Errorf("expected 4 added files, got %d added and %d removed", len(plan.Added), len(plan.Removed))
+	}
+}
+
+// TestRunSync_DryRun tests that a dry run reports the planned changes without touching the output
+// or the sync state
+func TestRunSync_DryRun(t *testing.T) {
+	cfg, src, _ := newTestSync(t)
+	filter, _ := docmost.NewSpaceFilter([]string{"slug:engineering"}, nil)
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("runSync failed: %v", err)
+	}
+
+	// Change the output so the dry run has something to report
+	spaceDir := filepath.Join(cfg.OutputDir, "Engineering")
+	before := readTree(t, spaceDir)
+	var removed, edited string
+	for name := range before {
+		switch {
+		case removed == "":
+			removed = name
+		case edited == "":
+			edited = name
+		}
+	}
+	if err := os.Remove(filepath.Join(spaceDir, removed)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(spaceDir, edited), []byte("local edit"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(spaceDir, "stray.md"), []byte("stray"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed := readTree(t, spaceDir)
+	stateBefore, err := hashFiles(cfg.StateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.FullSync = true
+	cfg.DryRun = true
+	cfg.DryRunReport = filepath.Join(t.TempDir(), "plan.json")
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("dry-run runSync failed: %v", err)
+	}
+
+	if after := readTree(t, spaceDir); !reflect.DeepEqual(after, changed) {
+		t.Errorf("dry run changed the output")
+	}
+	if stateAfter, _ := hashFiles(cfg.StateDir); !reflect.DeepEqual(stateAfter, stateBefore) {
+		t.Errorf("dry run changed the sync state")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "Engineering_temp")); !os.IsNotExist(err) {
+		t.Errorf("dry run left a temp directory in the output")
+	}
+
+	data, err := os.ReadFile(cfg.DryRunReport)
+	if err != nil {
+		t.Fatalf("plan not written: %v", err)
+	}
+	var plan syncPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		t.Fatalf("invalid plan: %v", err)
+	}
+	if len(plan.Spaces) != 1 {
+		t.Fatalf("expected 1 space in the plan, got %d", len(plan.Spaces))
+	}
+	sp := plan.Spaces[0]
+	rel := func(name string) string { return filepath.ToSlash(name) }
+	if !reflect.DeepEqual(sp.Added, []string{rel(removed)}) {
+		t.Errorf("added = %v, want [%s]", sp.Added, removed)
+	}
+	if !reflect.DeepEqual(sp.Removed, []string{"stray.md"}) {
+		t.Errorf("removed = %v, want [stray.md]", sp.Removed)
+	}
+	if !reflect.DeepEqual(sp.Modified, []string{rel(edited)}) {
+		t.Errorf("modified = %v, want [%s]", sp.Modified, edited)
+	}
+}
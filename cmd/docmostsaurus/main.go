@@ -3,98 +3,238 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"flag"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jung/doc2git/internal/config"
 	"github.com/jung/doc2git/internal/docmost"
 	"github.com/jung/doc2git/internal/health"
-	"github.com/jung/doc2git/internal/lock"
-	"github.com/jung/doc2git/internal/postprocess"
-	"github.com/jung/doc2git/internal/scheduler"
+	"github.com/jung/doc2git/internal/source"
+	"github.com/jung/doc2git/internal/syncstate"
 )
 
+// Exit codes of the commands
+const (
+	exitOK          = 0
+	exitFailed      = 1 // the sync or conversion failed, or the daemon reports a problem
+	exitUsage       = 2 // invalid command line
+	exitConfig      = 3 // invalid configuration
+	exitLocked      = 4 // another instance holds the lock
+	exitUnavailable = 5 // Docmost or the daemon could not be reached, or Docmost rejected the login
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"sync", "Sync all sources once and exit", runSyncCommand},
+	{"serve", "Sync every SYNC_INTERVAL and serve the health API until stopped", runServeCommand},
+	{"status", "Show the health of a running serve process", runStatusCommand},
+	{"convert", "Convert a downloaded export ZIP or directory without contacting Docmost", runConvert},
+	{"check", "Validate the configuration and the connection to every source", runCheckCommand},
+}
+
 func main() {
-	// Parse command line flags
-	outputDir := flag.String("output", "", "Output directory for exported markdown files (overrides OUTPUT_DIR env)")
-	oneShot := flag.Bool("once", false, "Run once and exit (ignore SYNC_INTERVAL)")
-	flag.Parse()
+	args := os.Args[1:]
 
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
+	// Without a command, behave like earlier releases: serve if SYNC_INTERVAL is set, otherwise sync once
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "-help" && args[0] != "--help") {
+		os.Exit(runDefault(args))
+	}
+
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		if len(args) > 1 {
+			name = args[1]
+			args = []string{name, "-h"}
+		} else {
+			printUsage(os.Stdout)
+			os.Exit(exitOK)
+		}
+	}
+	for _, c := range commands {
+		if c.name == name {
+			os.Exit(c.run(args[1:]))
+		}
 	}
 
-	// Override output directory if specified via flag
-	if *outputDir != "" {
-		cfg.OutputDir = *outputDir
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	os.Exit(exitUsage)
+}
+
+// printUsage prints the commands and exit codes
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: docmostsaurus <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.summary)
 	}
+	fmt.Fprintln(w, "\nRun 'docmostsaurus <command> -h' for the flags of a command.")
+	fmt.Fprintln(w, "Without a command, docmostsaurus serves if SYNC_INTERVAL is set and syncs once otherwise.")
+	fmt.Fprintln(w, "\nExit codes:")
+	fmt.Fprintln(w, "  0  success")
+	fmt.Fprintln(w, "  1  the sync or conversion failed, or the daemon reports a problem")
+	fmt.Fprintln(w, "  2  invalid command line")
+	fmt.Fprintln(w, "  3  invalid configuration")
+	fmt.Fprintln(w, "  4  another instance is already running")
+	fmt.Fprintln(w, "  5  Docmost or the daemon could not be reached, or Docmost rejected the login")
+}
 
-	// Override sync interval if one-shot mode
-	if *oneShot {
-		cfg.SyncInterval = 0
+// runDefault runs without a command, accepting the flags of earlier releases
+func runDefault(args []string) int {
+	flags := newFlagSet("docmostsaurus", "")
+	flags.Usage = func() { printUsage(flags.Output()) }
+	sf := addSyncFlags(flags)
+	addDryRunFlags(flags, sf)
+	oneShot := flags.Bool("once", false, "Run once and exit (ignore SYNC_INTERVAL)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
 	}
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
-		fmt.Fprintln(os.Stderr, "\nRequired environment variables:")
-		fmt.Fprintln(os.Stderr, "  DOCMOST_BASE_URL  - Docmost server URL (e.g., http://192.168.31.101:3456)")
-		fmt.Fprintln(os.Stderr, "  DOCMOST_EMAIL     - Docmost login email")
-		fmt.Fprintln(os.Stderr, "  DOCMOST_PASSWORD  - Docmost login password")
-		fmt.Fprintln(os.Stderr, "\nOptional environment variables:")
-		fmt.Fprintln(os.Stderr, "  OUTPUT_DIR        - Output directory (default: ./output)")
-		fmt.Fprintln(os.Stderr, "  SYNC_INTERVAL     - Sync interval (e.g., 30m, 2h). If empty, run once and exit")
-		fmt.Fprintln(os.Stderr, "  HTTP_PORT         - HTTP server port (default: :8080)")
-		os.Exit(1)
+	cfg, targets, code := setupSync(sf)
+	if code != exitOK {
+		return code
+	}
+	if *oneShot || cfg.SyncInterval <= 0 || cfg.DryRun {
+		return syncOnce(cfg, targets)
 	}
+	return serve(cfg, targets)
+}
+
+// syncTarget is a source together with the configuration it is synced with
+type syncTarget struct {
+	name   string // empty for the single source configured by environment variables
+	cfg    *config.Config
+	src    source.Source
+	filter *docmost.SpaceFilter
+}
 
-	// Acquire file lock to prevent concurrent instances
-	fileLock := lock.NewFileLock()
-	if err := fileLock.TryLock(); err != nil {
-		log.Fatalf("Failed to acquire lock: %v", err)
+// newSyncTargets creates a target for every source in SOURCES_FILE, or a single
+// unnamed target for the source configured by environment variables
+func newSyncTargets(cfg *config.Config) ([]*syncTarget, error) {
+	if len(cfg.Sources) == 0 {
+		t, err := newSyncTarget("", cfg)
+		if err != nil {
+			return nil, err
+		}
+		return []*syncTarget{t}, nil
 	}
-	defer fileLock.Unlock()
 
-	log.Println("=== Docmost Markdown Exporter ===")
-	log.Printf("Server: %s", cfg.DocmostBaseURL)
-	log.Printf("Output: %s", cfg.OutputDir)
-	if cfg.SyncInterval > 0 {
-		log.Printf("Sync Interval: %v", cfg.SyncInterval)
+	targets := make([]*syncTarget, 0, len(cfg.Sources))
+	for _, sc := range cfg.Sources {
+		t, err := newSyncTarget(sc.Name, cfg.ForSource(sc))
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", sc.Name, err)
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+func newSyncTarget(name string, cfg *config.Config) (*syncTarget, error) {
+	filter, err := docmost.NewSpaceFilter(cfg.SpaceInclude, cfg.SpaceExclude)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := newPipelineSet(cfg); err != nil {
+		return nil, err
+	}
+	src, err := newSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &syncTarget{name: name, cfg: cfg, src: src, filter: filter}, nil
+}
+
+// logSummary logs where the target reads from and writes to
+func (t *syncTarget) logSummary() {
+	prefix := ""
+	if t.name != "" {
+		prefix = fmt.Sprintf("[%s] ", t.name)
+	}
+	if t.cfg.SourceType == config.SourceLocal {
+		log.Printf("%sSource: local directory %s", prefix, t.cfg.SourceDir)
 	} else {
-		log.Println("Mode: One-shot (run once and exit)")
+		log.Printf("%sServer: %s", prefix, t.cfg.DocmostBaseURL)
 	}
+	log.Printf("%sOutput: %s", prefix, t.cfg.OutputDir)
+}
 
-	// Start HTTP server (health check + future API endpoints)
-	healthChecker := health.NewChecker(cfg.SyncInterval)
-	healthServer := health.NewServer(healthChecker, cfg.HTTPPort)
-	healthServer.Start()
-	log.Printf("HTTP server started on %s", cfg.HTTPPort)
-	defer healthServer.Stop()
+// label names the target in messages: its name, or where it reads from
+func (t *syncTarget) label() string {
+	switch {
+	case t.name != "":
+		return t.name
+	case t.cfg.SourceType == config.SourceLocal:
+		return t.cfg.SourceDir
+	case t.cfg.ReplayDir != "":
+		return t.cfg.ReplayDir
+	}
+	return t.cfg.DocmostBaseURL
+}
 
-	// Create scheduler with sync function
-	sched := scheduler.NewScheduler(cfg, func(ctx context.Context, cfg *config.Config) error {
-		healthChecker.SetRunning(true)
-		defer healthChecker.SetRunning(false)
+// session returns the login session of a Docmost source, or nil
+func (t *syncTarget) session() *health.SessionStatus {
+	d, ok := t.src.(*source.Docmost)
+	if !ok {
+		return nil
+	}
+	session := d.Client().Session()
+	return &health.SessionStatus{
+		Authenticated: session.LoggedIn,
+		LastLogin:     session.LastLogin,
+		Logins:        session.Logins,
+		LastError:     session.LastError,
+	}
+}
 
-		err := runSync(ctx, cfg)
-		healthChecker.UpdateSyncStatus(err)
-		return err
-	})
+// runTargets syncs the targets one after another and records the outcome of each in
+// the health checker. A failed source does not stop the others.
+func runTargets(ctx context.Context, targets []*syncTarget, checker *health.Checker) error {
+	var errs []error
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
 
-	// Start scheduler (blocks until shutdown)
-	sched.Start()
+		if t.name == "" {
+			err := runSync(ctx, t.cfg, t.src, t.filter)
+			if session := t.session(); session != nil {
+				checker.UpdateSession(*session)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
 
-	log.Println("=== Shutdown Complete ===")
+		log.Printf("=== Source: %s ===", t.name)
+		err := runSync(ctx, t.cfg, t.src, t.filter)
+		checker.UpdateSource(t.name, err, t.session())
+		if err != nil {
+			log.Printf("Warning: source %s failed: %v", t.name, err)
+			errs = append(errs, fmt.Errorf("source %s: %w", t.name, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // runSync performs a single sync operation
-func runSync(ctx context.Context, cfg *config.Config) error {
+func runSync(ctx context.Context, cfg *config.Config, src source.Source, filter *docmost.SpaceFilter) error {
 	// Check for cancellation
 	select {
 	case <-ctx.Done():
@@ -102,228 +242,482 @@ func runSync(ctx context.Context, cfg *config.Config) error {
 	default:
 	}
 
-	// Create Docmost client
-	client, err := docmost.NewClient(cfg.DocmostBaseURL, cfg.DocmostEmail, cfg.DocmostPassword)
-	if err != nil {
-		return fmt.Errorf("error creating client: %w", err)
+	// Record every run into a bundle of its own
+	if recorder, ok := src.(source.Recorder); ok {
+		if err := recorder.StartRecording(); err != nil {
+			return err
+		}
 	}
 
-	// Login
-	log.Println("Logging in to Docmost...")
-	if err := client.Login(); err != nil {
-		return fmt.Errorf("login failed: %w", err)
+	// List all spaces; each one is exported, post-processed and swapped in by a pool of SYNC_CONCURRENCY workers
+	log.Println("Exporting all spaces...")
+	spaces, err := src.Collections(ctx)
+	if err != nil {
+		return &collectionsError{err: err}
 	}
-	log.Println("Login successful!")
 
-	// Export all spaces
-	log.Println("Exporting all spaces...")
-	exportedSpaces, err := client.ExportAllSpaces()
+	pipelines, err := newPipelineSet(cfg)
 	if err != nil {
-		return fmt.Errorf("export failed: %w", err)
+		return err
+	}
+	s := &syncer{cfg: cfg, src: src, store: syncstate.NewStore(cfg.StateDir), pipelines: pipelines}
+
+	// A dry run builds every space in a scratch directory and leaves the output and sync state alone
+	if cfg.DryRun {
+		s.scratchDir, err = os.MkdirTemp("", "docmostsaurus-dry-run-")
+		if err != nil {
+			return fmt.Errorf("failed to create dry-run directory: %w", err)
+		}
+		defer os.RemoveAll(s.scratchDir)
 	}
+	plan := &syncPlan{OutputDir: cfg.OutputDir, GeneratedAt: time.Now().UTC()}
 
-	if len(exportedSpaces) == 0 {
+	// Drop spaces that must not be published before anything is downloaded
+	spaces, excluded := filter.Apply(spaces)
+	for _, space := range excluded {
+		log.Printf("Skipping excluded space: %s (%s)", space.Name, space.ID)
+	}
+	if cfg.RemoveExcludedSpaces {
+		plan.RemovedSpaces = s.removeExcludedSpaces(spaces, excluded)
+	}
+
+	if len(spaces) == 0 {
 		log.Println("No spaces found to export.")
+		if cfg.DryRun {
+			return reportPlan(cfg, plan)
+		}
 		return nil
 	}
 
-	// Save exported files to output directory
+	results := s.syncSpaces(ctx, spaces)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	// Collect per-space errors; a failed space does not abort the others
 	totalFiles := 0
-	for _, exported := range exportedSpaces {
-		// Check for cancellation between spaces
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+	syncedSpaces := 0
+	skippedSpaces := 0
+	var spaceErrors []error
+	for _, result := range results {
+		if cfg.DryRun {
+			plan.Spaces = append(plan.Spaces, result.plan())
+		}
+		if result.err != nil {
+			log.Printf("Warning: failed to sync space %s: %v", result.space.Name, result.err)
+			spaceErrors = append(spaceErrors, fmt.Errorf("space %s: %w", result.space.Name, result.err))
+			continue
+		}
+		if result.skipped {
+			skippedSpaces++
+			continue
+		}
+		totalFiles += result.fileCount
+		syncedSpaces++
+	}
+
+	log.Println("=== Sync Complete ===")
+	log.Printf("Total spaces: %d", syncedSpaces)
+	log.Printf("Unchanged:    %d", skippedSpaces)
+	log.Printf("Total files:  %d", totalFiles)
+	log.Printf("Output dir:   %s", cfg.OutputDir)
+
+	if cfg.DryRun {
+		if err := reportPlan(cfg, plan); err != nil {
+			return err
 		}
+	}
 
-		spaceName := sanitizeDirName(exported.Space.Name)
-		spaceDir := filepath.Join(cfg.OutputDir, spaceName)
-		spaceDirTemp := filepath.Join(cfg.OutputDir, spaceName+"_temp")
-		spaceDirOld := filepath.Join(cfg.OutputDir, spaceName+"_old")
+	if len(spaceErrors) > 0 {
+		return fmt.Errorf("%d of %d spaces failed: %w", len(spaceErrors), len(spaces), errors.Join(spaceErrors...))
+	}
+	return nil
+}
 
-		// Clean up any existing temp directory from previous failed runs
-		cleanupTempDir(spaceDirTemp)
+// collectionsError is returned by runSync if the spaces of the source could not be
+// listed, so the run failed before any space was synced
+type collectionsError struct {
+	err error
+}
 
-		// Create temp directory for atomic swap
-		if err := os.MkdirAll(spaceDirTemp, 0755); err != nil {
-			log.Printf("Error creating temp directory %s: %v", spaceDirTemp, err)
+func (e *collectionsError) Error() string {
+	return "export failed: " + e.err.Error()
+}
+
+func (e *collectionsError) Unwrap() error {
+	return e.err
+}
+
+// syncer exports spaces into the output directory during a sync run
+type syncer struct {
+	cfg        *config.Config
+	src        source.Source
+	store      *syncstate.Store
+	pipelines  *pipelineSet
+	scratchDir string // where spaces are built in a dry run
+}
+
+// removeExcludedSpaces deletes the output directories and saved sync state of excluded spaces
+// and returns the deleted directories. A directory that also belongs to an exported space
+// (same sanitized name) is kept. In a dry run, nothing is deleted.
+func (s *syncer) removeExcludedSpaces(spaces, excluded []docmost.Space) []string {
+	exportedDirs := make(map[string]bool, len(spaces))
+	for _, space := range spaces {
+		exportedDirs[sanitizeDirName(space.Name)] = true
+	}
+
+	var removed []string
+	for _, space := range excluded {
+		if !s.cfg.DryRun {
+			if err := s.store.Remove(space.ID); err != nil {
+				log.Printf("Warning: failed to remove sync state of excluded space %s: %v", space.Name, err)
+			}
+		}
+
+		dirName := sanitizeDirName(space.Name)
+		if exportedDirs[dirName] {
+			continue
+		}
+		spaceDir := filepath.Join(s.cfg.OutputDir, dirName)
+		if _, err := os.Stat(spaceDir); err != nil {
+			continue
+		}
+		removed = append(removed, spaceDir)
+		if s.cfg.DryRun {
 			continue
 		}
+		log.Printf("Removing output of excluded space %s: %s", space.Name, spaceDir)
+		if err := os.RemoveAll(spaceDir); err != nil {
+			log.Printf("Warning: failed to remove %s: %v", spaceDir, err)
+		}
+	}
+	return removed
+}
 
-		// Track if any error occurred during processing
-		var processingError error
+// spaceResult is the outcome of syncing a single space
+type spaceResult struct {
+	space     docmost.Space
+	fileCount int
+	skipped   bool // the space was unchanged since the last sync
+	err       error
+	changes   *spacePlan // changes found by a dry run
+}
 
-		// Write files to temp directory
-		for filename, content := range exported.Files {
-			filePath := filepath.Join(spaceDirTemp, filename)
+// plan returns the dry-run plan entry of the result
+func (r spaceResult) plan() spacePlan {
+	sp := spacePlan{}
+	if r.changes != nil {
+		sp = *r.changes
+	}
+	sp.Space = r.space.Name
+	sp.SpaceID = r.space.ID
+	sp.Dir = sanitizeDirName(r.space.Name)
+	sp.Unchanged = r.skipped
+	if r.err != nil {
+		sp.Error = r.err.Error()
+	}
+	return sp
+}
 
-			// Create parent directories if needed
-			parentDir := filepath.Dir(filePath)
-			if err := os.MkdirAll(parentDir, 0755); err != nil {
-				log.Printf("Error creating directory %s: %v", parentDir, err)
+// syncSpaces syncs spaces using up to cfg.SyncConcurrency workers.
+// Results are returned in the order of spaces. Spaces whose directory name collides
+// with an earlier space are not synced, since both would write to the same directory.
+func (s *syncer) syncSpaces(ctx context.Context, spaces []docmost.Space) []spaceResult {
+	results := make([]spaceResult, len(spaces))
+	dirOwners := make(map[string]string)
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i, space := range spaces {
+			results[i].space = space
+
+			dirName := sanitizeDirName(space.Name)
+			if owner, ok := dirOwners[dirName]; ok {
+				results[i].err = fmt.Errorf("output directory %q is already used by space %s", dirName, owner)
 				continue
 			}
+			dirOwners[dirName] = space.Name
 
-			// Write file
-			if err := os.WriteFile(filePath, content, 0644); err != nil {
-				log.Printf("Error writing file %s: %v", filePath, err)
-				continue
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
 			}
-
-			totalFiles++
 		}
+	}()
 
-		// Save metadata JSON file to temp directory
-		if exported.Metadata != nil {
-			metaPath := filepath.Join(spaceDirTemp, "_metadata.json")
-			metaData, err := json.MarshalIndent(exported.Metadata, "", "  ")
-			if err != nil {
-				log.Printf("Error marshaling metadata for %s: %v", exported.Space.Name, err)
-				processingError = err
-			} else {
-				if err := os.WriteFile(metaPath, metaData, 0644); err != nil {
-					log.Printf("Error writing metadata file %s: %v", metaPath, err)
-					processingError = err
-				} else {
-					log.Printf("Space '%s': metadata saved to %s", exported.Space.Name, metaPath)
-				}
+	workers := s.cfg.SyncConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.syncSpace(ctx, spaces[i])
 			}
-		}
+		}()
+	}
+	wg.Wait()
 
-		// Check for errors before post-processing
-		if processingError != nil {
-			log.Printf("Skipping space '%s' due to errors, cleaning up temp directory", exported.Space.Name)
-			cleanupTempDir(spaceDirTemp)
-			continue
-		}
+	return results
+}
 
-		log.Printf("Space '%s': %d files saved to %s", exported.Space.Name, len(exported.Files), spaceDirTemp)
+// syncSpace exports a single space into a temp directory, post-processes it
+// and atomically swaps it into place. The page tree is crawled first; if it matches
+// the state saved by the last successful sync, the export is skipped entirely, and if
+// only page contents changed, just those pages are exported (see patchSpace).
+func (s *syncer) syncSpace(ctx context.Context, space docmost.Space) spaceResult {
+	result := spaceResult{space: space}
+	started := time.Now()
+
+	spaceName := sanitizeDirName(space.Name)
+	spaceDir := filepath.Join(s.cfg.OutputDir, spaceName)
+	spaceDirTemp := filepath.Join(s.cfg.OutputDir, spaceName+"_temp")
+	if s.cfg.DryRun {
+		spaceDirTemp = filepath.Join(s.scratchDir, spaceName)
+	}
+	spaceDirOld := filepath.Join(s.cfg.OutputDir, spaceName+"_old")
 
-		// Post-process: Fix files/folders split due to "/" in title and update _metadata.json
-		log.Printf("Post-processing: Fixing slash-split files and titles in %s...", spaceDirTemp)
-		if err := postprocess.FixSlashInTitles(spaceDirTemp); err != nil {
-			log.Printf("Warning: failed to fix slash-split files: %v", err)
-		}
+	log.Printf("Fetching page tree for space: %s (%s)", space.Name, space.ID)
+	meta, err := s.src.PageTree(ctx, space)
+	if err != nil {
+		result.err = fmt.Errorf("failed to get space metadata: %w", err)
+		return result
+	}
+	state := syncstate.FromMetadata(meta)
+	if missing := state.MissingTimestamps(); missing > 0 {
+		log.Printf("Warning: space '%s': %d of %d pages have no updatedAt timestamp, so the space cannot be skipped or patched and is exported in full",
+			space.Name, missing, len(state.Pages))
+	}
 
-		// Post-process: Remove orphaned files not in _metadata.json
-		log.Printf("Post-processing: Removing orphaned files in %s...", spaceDirTemp)
-		if err := postprocess.RemoveOrphanedFiles(spaceDirTemp); err != nil {
-			log.Printf("Warning: failed to remove orphaned files: %v", err)
+	// The previous state is also loaded for a full sync, to report the page changes
+	previous, err := s.store.Load(space.ID)
+	if err != nil {
+		log.Printf("Warning: ignoring sync state of space '%s': %v", space.Name, err)
+	}
+	if !s.cfg.FullSync {
+		if _, statErr := os.Stat(spaceDir); statErr == nil && state.Unchanged(previous) {
+			log.Printf("Space '%s': unchanged since %s, skipping", space.Name, previous.SyncedAt.Format(time.RFC3339))
+			result.skipped = true
+			return result
 		}
+	}
 
-		// Post-process: Wrap placeholders with backticks (before frontmatter)
-		log.Printf("Post-processing: Wrapping placeholders with backticks in %s...", spaceDirTemp)
-		if err := postprocess.WrapPlaceholdersWithBackticks(spaceDirTemp); err != nil {
-			log.Printf("Warning: failed to wrap placeholders: %v", err)
-		}
+	// Clean up any existing temp directory from previous failed runs
+	cleanupTempDir(spaceDirTemp)
+
+	// Patch changed pages into the raw export saved by the last sync, if the page tree still matches it
+	files, patched := s.patchSpace(ctx, space, meta, spaceDirTemp)
+	if ctx.Err() != nil {
+		cleanupTempDir(spaceDirTemp)
+		result.err = ctx.Err()
+		return result
+	}
 
-		// Post-process: Wrap angle brackets with backticks (before frontmatter)
-		log.Printf("Post-processing: Wrapping angle brackets with backticks in %s...", spaceDirTemp)
-		if err := postprocess.WrapAngleBracketsWithBackticks(spaceDirTemp); err != nil {
-			log.Printf("Warning: failed to wrap angle brackets: %v", err)
+	if !patched {
+		// Create temp directory for atomic swap
+		if err := os.MkdirAll(spaceDirTemp, 0755); err != nil {
+			result.err = fmt.Errorf("error creating temp directory %s: %w", spaceDirTemp, err)
+			return result
 		}
 
-		// Post-process: Wrap raw HTML (like tables) with code blocks
-		log.Printf("Post-processing: Wrapping raw HTML with code blocks in %s...", spaceDirTemp)
-		if err := postprocess.WrapRawHTMLWithCodeBlock(spaceDirTemp); err != nil {
-			log.Printf("Warning: failed to wrap raw HTML: %v", err)
+		// Fetch the space straight into the temp directory
+		files, err = s.src.FetchFiles(ctx, space, meta, spaceDirTemp)
+		if err != nil {
+			cleanupTempDir(spaceDirTemp)
+			result.err = err
+			return result
 		}
+	}
 
-		// Post-process: Merge files that were incorrectly split due to "/" in title (BEFORE romanization)
-		// This handles Korean filenames like "Security365 환경 인증/인가 관련 공통 에러 페이지.md"
-		log.Printf("Post-processing: Merging slash-split files (before romanization) in %s...", spaceDirTemp)
-		if err := postprocess.MergeSlashSplitFiles(spaceDirTemp); err != nil {
-			log.Printf("Warning: failed to merge slash-split files: %v", err)
+	// files holds only the re-exported pages of a patched space, count the whole export
+	fileCount := len(files)
+	if patched {
+		if fileCount, err = countExportFiles(spaceDirTemp); err != nil {
+			cleanupTempDir(spaceDirTemp)
+			result.err = fmt.Errorf("error counting files of %s: %w", spaceDirTemp, err)
+			return result
 		}
+	}
 
-		// Post-process: Romanize Korean filenames and add frontmatter
-		log.Printf("Post-processing: Romanizing Korean filenames in %s...", spaceDirTemp)
-		results, err := postprocess.RomanizeSpace(spaceDirTemp)
-		if err != nil {
-			log.Printf("Warning: failed to romanize space %s: %v", exported.Space.Name, err)
+	// Save metadata JSON file to temp directory
+	metaPath := filepath.Join(spaceDirTemp, "_metadata.json")
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err == nil {
+		err = os.WriteFile(metaPath, metaData, 0644)
+	}
+	if err != nil {
+		log.Printf("Skipping space '%s' due to errors, cleaning up temp directory", space.Name)
+		cleanupTempDir(spaceDirTemp)
+		result.err = fmt.Errorf("error writing metadata file %s: %w", metaPath, err)
+		return result
+	}
+	log.Printf("Space '%s': metadata saved to %s", space.Name, metaPath)
+
+	// Keep the unprocessed export as the base for page-level updates; without it the next sync exports the whole space
+	if !s.cfg.DryRun {
+		if patched {
+			err = s.store.SavePatchedRaw(space.ID, spaceDirTemp, files)
 		} else {
-			for _, r := range results {
-				if r.OriginalPath != r.RomanizedPath {
-					log.Printf("  Renamed: %s -> %s", r.OriginalPath, r.RomanizedPath)
-				}
-				if r.FrontmatterAdded {
-					log.Printf("  Added frontmatter: %s (title: %s)", r.RomanizedPath, r.OriginalTitle)
-				}
-			}
+			err = s.store.SaveRaw(space.ID, spaceDirTemp)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to save raw export of space '%s': %v", space.Name, err)
+		}
+	}
 
-			// Move files into matching folders (e.g., meomeideu.md -> meomeideu/meomeideu.md)
-			log.Printf("Post-processing: Moving files into matching folders in %s...", spaceDirTemp)
-			if err := postprocess.MoveFilesIntoMatchingFolders(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to move files into folders: %v", err)
-			}
+	ppReport := postProcessSpace(spaceDirTemp, space.Name, s.pipelines.forSpace(space))
 
-			// Merge Korean folders into romanized folders (e.g., 머메이드/files -> meomeideu/files)
-			log.Printf("Post-processing: Merging Korean folder contents into romanized folders in %s...", spaceDirTemp)
-			if err := postprocess.MergeKoreanFoldersIntoRomanized(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to merge Korean folders: %v", err)
-			}
+	if s.cfg.DryRun {
+		result.changes, result.err = diffDirs(spaceDir, spaceDirTemp)
+		cleanupTempDir(spaceDirTemp)
+		result.fileCount = fileCount
+		return result
+	}
 
-			// Rename any remaining Korean folders to romanized names
-			log.Printf("Post-processing: Renaming remaining Korean folders in %s...", spaceDirTemp)
-			if err := postprocess.RenameRemainingKoreanFolders(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to rename remaining Korean folders: %v", err)
-			}
+	// Carry the change history of the previous output over into the new one
+	if s.cfg.SyncReportRuns > 0 {
+		run := newSyncRun(started, state.Changes(previous), ppReport, fileCount, len(files), patched)
+		if err := writeSyncReport(spaceDirTemp, spaceDir, space, run, s.cfg.SyncReportRuns); err != nil {
+			log.Printf("Warning: failed to write sync report of space '%s': %v", space.Name, err)
+		}
+	}
 
-			// Rename any remaining Korean .md files to romanized names
-			log.Printf("Post-processing: Renaming remaining Korean files in %s...", spaceDirTemp)
-			if err := postprocess.RenameRemainingKoreanFiles(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to rename remaining Korean files: %v", err)
-			}
+	// Perform atomic swap: replace old directory with new one
+	log.Printf("Performing atomic swap for space '%s'...", space.Name)
+	if err := atomicSwap(spaceDir, spaceDirTemp, spaceDirOld); err != nil {
+		cleanupTempDir(spaceDirTemp)
+		result.err = fmt.Errorf("error during atomic swap: %w", err)
+		return result
+	}
+	log.Printf("Space '%s': successfully swapped to %s", space.Name, spaceDir)
 
-			// Sanitize special characters in folder and .md file names (e.g., & -> -and-)
-			log.Printf("Post-processing: Sanitizing special characters in %s...", spaceDirTemp)
-			if err := postprocess.SanitizeSpecialCharacters(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to sanitize special characters: %v", err)
-			}
+	// Record the synced state; a missing state only costs a full export next time
+	state.SyncedAt = time.Now()
+	if err := s.store.Save(state); err != nil {
+		log.Printf("Warning: failed to save sync state of space '%s': %v", space.Name, err)
+	}
 
-			// Remove space before .md extension (e.g., "OIDC .md" -> "OIDC.md")
-			log.Printf("Post-processing: Removing space before extension in %s...", spaceDirTemp)
-			if err := postprocess.RemoveSpaceBeforeExtension(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to remove space before extension: %v", err)
-			}
+	result.fileCount = fileCount
+	return result
+}
 
-			// Move files into matching folders again (after sanitization, folder/file names may now match)
-			// e.g., sihaengchako.md -> sihaengchako/sihaengchako.md
-			log.Printf("Post-processing: Moving files into matching folders (after sanitization) in %s...", spaceDirTemp)
-			if err := postprocess.MoveFilesIntoMatchingFolders(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to move files into folders: %v", err)
-			}
+// patchSpace rebuilds a space in dir from the raw export saved by the last sync,
+// re-exporting only the pages that changed since then. It reports false if the space
+// needs a full export instead, in which case dir does not exist on return.
+func (s *syncer) patchSpace(ctx context.Context, space docmost.Space, meta *docmost.SpaceMeta, dir string) ([]string, bool) {
+	patcher, ok := s.src.(source.Patcher)
+	if !ok || s.cfg.FullSync {
+		return nil, false
+	}
 
-			// Merge files that were incorrectly split due to "/" in title (AFTER romanization)
-			// This handles romanized filenames like "Security365-hwangyeong-injeung/inga-gwanryeon-gongtong-ereo-peiji.md"
-			log.Printf("Post-processing: Merging slash-split files (after romanization) in %s...", spaceDirTemp)
-			if err := postprocess.MergeSlashSplitFiles(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to merge slash-split files: %v", err)
-			}
+	previous, err := s.store.LoadRaw(space.ID, dir)
+	if err != nil {
+		log.Printf("Warning: ignoring raw export of space '%s': %v", space.Name, err)
+	}
+	if previous == nil {
+		cleanupTempDir(dir)
+		return nil, false
+	}
 
-			// Cleanup empty directories
-			if err := postprocess.CleanupEmptyDirs(spaceDirTemp); err != nil {
-				log.Printf("Warning: failed to cleanup empty dirs: %v", err)
-			}
+	files, err := patcher.PatchFiles(ctx, previous, meta, dir)
+	if err != nil {
+		if errors.Is(err, docmost.ErrStructureChanged) || errors.Is(err, docmost.ErrPageExportUnsupported) {
+			log.Printf("Space '%s': %v, exporting the whole space", space.Name, err)
+		} else if ctx.Err() == nil {
+			log.Printf("Warning: page export failed for space '%s', exporting the whole space: %v", space.Name, err)
 		}
+		cleanupTempDir(dir)
+		return nil, false
+	}
 
-		// Perform atomic swap: replace old directory with new one
-		log.Printf("Performing atomic swap for space '%s'...", exported.Space.Name)
-		if err := atomicSwap(spaceDir, spaceDirTemp, spaceDirOld); err != nil {
-			log.Printf("Error during atomic swap for %s: %v", exported.Space.Name, err)
-			cleanupTempDir(spaceDirTemp)
-			continue
+	log.Printf("Space '%s': %d files updated from page exports", space.Name, len(files))
+	return files, true
+}
+
+// countExportFiles counts the regular files of the export in dir, without its _metadata.json
+func countExportFiles(dir string) (int, error) {
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
 		}
-		log.Printf("Space '%s': successfully swapped to %s", exported.Space.Name, spaceDir)
+		if path != filepath.Join(dir, "_metadata.json") {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// reportPlan prints the plan of a dry run and writes it to cfg.DryRunReport if set
+func reportPlan(cfg *config.Config, plan *syncPlan) error {
+	plan.print(os.Stdout)
+	if cfg.DryRunReport == "" {
+		return nil
+	}
+	if err := plan.write(cfg.DryRunReport); err != nil {
+		return fmt.Errorf("failed to write dry-run report: %w", err)
 	}
+	log.Printf("Dry-run report written to %s", cfg.DryRunReport)
+	return nil
+}
 
-	log.Println("=== Sync Complete ===")
-	log.Printf("Total spaces: %d", len(exportedSpaces))
-	log.Printf("Total files:  %d", totalFiles)
-	log.Printf("Output dir:   %s", cfg.OutputDir)
+// newSource creates the content source selected by cfg
+func newSource(cfg *config.Config) (source.Source, error) {
+	if cfg.SourceType == config.SourceLocal {
+		return source.NewLocal(cfg.SourceDir), nil
+	}
+	client, err := newDocmostClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return source.NewDocmost(client, cfg.RootPage), nil
+}
 
-	return nil
+// newDocmostClient creates a Docmost client configured from cfg
+func newDocmostClient(cfg *config.Config) (*docmost.Client, error) {
+	opts := []docmost.Option{
+		docmost.WithRetryPolicy(docmost.RetryPolicy{
+			MaxRetries: cfg.DocmostMaxRetries,
+			BaseDelay:  cfg.DocmostRetryBaseDelay,
+			MaxDelay:   cfg.DocmostRetryMaxDelay,
+		}),
+		docmost.WithRateLimit(cfg.DocmostRateLimit),
+		docmost.WithTimeout(cfg.DocmostTimeout),
+		docmost.WithVersionCheck(!cfg.DocmostSkipVersionCheck),
+		docmost.WithTransport(docmost.TransportConfig{
+			CAFile:   cfg.DocmostCAFile,
+			CertFile: cfg.DocmostClientCertFile,
+			KeyFile:  cfg.DocmostClientKeyFile,
+			ProxyURL: cfg.DocmostProxyURL,
+		}),
+		docmost.WithCrawlConcurrency(cfg.DocmostCrawlConcurrency),
+		docmost.WithExtractLimits(docmost.ExtractLimits{
+			MaxTotalSize:        cfg.ZipMaxTotalSize,
+			MaxFiles:            cfg.ZipMaxFiles,
+			MaxCompressionRatio: cfg.ZipMaxCompressionRatio,
+		}),
+	}
+	if cfg.DocmostAPIToken != "" {
+		opts = append(opts, docmost.WithAPIToken(cfg.DocmostAPIToken))
+	}
+	if cfg.DocmostAuthCookie != "" {
+		opts = append(opts, docmost.WithAuthCookie(cfg.DocmostAuthCookie))
+	}
+	if cfg.RecordDir != "" {
+		opts = append(opts, docmost.WithRecorder(cfg.RecordDir))
+	}
+	if cfg.ReplayDir != "" {
+		opts = append(opts, docmost.WithReplay(cfg.ReplayDir))
+	}
+
+	return docmost.NewClient(cfg.DocmostBaseURL, cfg.DocmostEmail, cfg.DocmostPassword, opts...)
 }
 
 // sanitizeDirName creates a safe directory name
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jung/doc2git/internal/config"
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/postprocess"
+)
+
+// pipelineSet selects the post-processing pipeline of each space
+type pipelineSet struct {
+	base      *postprocess.Pipeline
+	overrides []pipelineOverride
+}
+
+// pipelineOverride is the pipeline of the spaces matching filter
+type pipelineOverride struct {
+	filter   *docmost.SpaceFilter
+	pipeline *postprocess.Pipeline
+}
+
+// newPipelineSet builds the pipelines configured by POSTPROCESS_STEPS, POSTPROCESS_DISABLE
+// and POSTPROCESS_FILE, failing on unknown steps or invalid space rules
+func newPipelineSet(cfg *config.Config) (*pipelineSet, error) {
+	base, err := postprocess.NewPipeline(cfg.PostprocessSteps, cfg.PostprocessDisable)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &pipelineSet{base: base}
+	for i, o := range cfg.PostprocessSpaces {
+		filter, err := docmost.NewSpaceFilter(o.Spaces, nil)
+		if err != nil {
+			return nil, fmt.Errorf("POSTPROCESS_FILE entry %d: %w", i+1, err)
+		}
+
+		steps, disabled := o.Steps, o.Disable
+		if len(steps) == 0 {
+			steps = cfg.PostprocessSteps
+			disabled = append(append([]string(nil), cfg.PostprocessDisable...), o.Disable...)
+		}
+		pipeline, err := postprocess.NewPipeline(steps, disabled)
+		if err != nil {
+			return nil, fmt.Errorf("POSTPROCESS_FILE entry %d: %w", i+1, err)
+		}
+		ps.overrides = append(ps.overrides, pipelineOverride{filter: filter, pipeline: pipeline})
+	}
+	return ps, nil
+}
+
+// forSpace returns the pipeline of the first override matching space, or the base pipeline
+func (ps *pipelineSet) forSpace(space docmost.Space) *postprocess.Pipeline {
+	for _, o := range ps.overrides {
+		if o.filter.Allows(space) {
+			return o.pipeline
+		}
+	}
+	return ps.base
+}
+
+// postProcessSpace converts an exported space directory to the Docusaurus format in place
+// and logs what every step did
+func postProcessSpace(spaceDir, spaceName string, pipeline *postprocess.Pipeline) *postprocess.Report {
+	log.Printf("Post-processing space '%s' in %s...", spaceName, spaceDir)
+	report := pipeline.Run(spaceDir)
+
+	for _, step := range report.Steps {
+		if step.Skipped {
+			log.Printf("  %s: skipped", step.Name)
+			continue
+		}
+		log.Printf("  %s: %d files changed in %s", step.Name, step.Changed, step.Duration.Round(time.Millisecond))
+		for _, r := range step.Renames {
+			if r.OriginalPath != r.RomanizedPath {
+				log.Printf("    Renamed: %s -> %s", r.OriginalPath, r.RomanizedPath)
+			}
+			if r.FrontmatterAdded {
+				log.Printf("    Added frontmatter: %s (title: %s)", r.RomanizedPath, r.OriginalTitle)
+			}
+		}
+		for _, w := range step.Warnings {
+			log.Printf("Warning: %s in space '%s': %s", step.Name, spaceName, w)
+		}
+	}
+	return report
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/postprocess"
+	"github.com/jung/doc2git/internal/syncstate"
+)
+
+// syncReportFile is the change history kept in the output directory of every synced space
+const syncReportFile = "_sync-report.json"
+
+// syncReport is the content of syncReportFile: what each of the last runs changed in a space
+type syncReport struct {
+	Space   string    `json:"space"`
+	SpaceID string    `json:"spaceId"`
+	Runs    []syncRun `json:"runs"` // newest first
+}
+
+// syncRun describes one run that rebuilt the space. Paths are "/"-separated and relative
+// to the space directory; durations are in milliseconds.
+type syncRun struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Export     string    `json:"export"` // "space" for a full space export, "pages" for page-level updates
+	Files      int       `json:"files"`  // files in the export
+
+	// UpdatedFiles are the files re-exported by page-level updates; the other files were
+	// kept from the previous export
+	UpdatedFiles int `json:"updatedFiles,omitempty"`
+
+	Pages          *syncstate.Changes `json:"pages"`
+	Romanized      []romanizedFile    `json:"romanized"`
+	OrphansRemoved []string           `json:"orphansRemoved"`
+	Steps          []stepSummary      `json:"steps"`
+}
+
+// romanizedFile is a file renamed by the romanize post-processing step
+type romanizedFile struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Title string `json:"title"`
+}
+
+// stepSummary is the outcome of one post-processing step
+type stepSummary struct {
+	Name       string   `json:"name"`
+	DurationMs int64    `json:"durationMs"`
+	Changed    int      `json:"changed"`
+	Skipped    bool     `json:"skipped,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// newSyncRun builds the report entry of a run from the page changes and the pipeline report.
+// updated is the number of files re-exported by page-level updates if patched.
+func newSyncRun(started time.Time, pages *syncstate.Changes, pp *postprocess.Report, files, updated int, patched bool) syncRun {
+	run := syncRun{
+		StartedAt:      started.UTC(),
+		DurationMs:     time.Since(started).Milliseconds(),
+		Export:         "space",
+		Files:          files,
+		Pages:          pages,
+		Romanized:      []romanizedFile{},
+		OrphansRemoved: []string{},
+		Steps:          make([]stepSummary, 0, len(pp.Steps)),
+	}
+	if patched {
+		run.Export = "pages"
+		run.UpdatedFiles = updated
+	}
+
+	for _, step := range pp.Steps {
+		for _, r := range step.Renames {
+			if r.OriginalPath == r.RomanizedPath {
+				continue // only frontmatter was added
+			}
+			run.Romanized = append(run.Romanized, romanizedFile{
+				From:  filepath.ToSlash(r.OriginalPath),
+				To:    filepath.ToSlash(r.RomanizedPath),
+				Title: r.OriginalTitle,
+			})
+		}
+		run.OrphansRemoved = append(run.OrphansRemoved, step.Removed...)
+		run.Steps = append(run.Steps, stepSummary{
+			Name:       step.Name,
+			DurationMs: step.Duration.Milliseconds(),
+			Changed:    step.Changed,
+			Skipped:    step.Skipped,
+			Warnings:   step.Warnings,
+		})
+	}
+	return run
+}
+
+// writeSyncReport adds run to the report of the previous output in prevDir and writes the
+// result into dir, keeping at most keep runs. An unreadable previous report is started over.
+func writeSyncReport(dir, prevDir string, space docmost.Space, run syncRun, keep int) error {
+	report := &syncReport{}
+	if data, err := os.ReadFile(filepath.Join(prevDir, syncReportFile)); err == nil {
+		if err := json.Unmarshal(data, report); err != nil {
+			report = &syncReport{}
+		}
+	}
+	report.Space = space.Name
+	report.SpaceID = space.ID
+	report.Runs = append([]syncRun{run}, report.Runs...)
+	if len(report.Runs) > keep {
+		report.Runs = report.Runs[:keep]
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, syncReportFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", syncReportFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/syncstate"
+)
+
+// TestRunSync_WritesSyncReport tests that every run that rebuilds a space is added to its sync report,
+// newest first and limited to SyncReportRuns runs
+func TestRunSync_WritesSyncReport(t *testing.T) {
+	cfg, src, _ := newTestSync(t)
+	cfg.SyncReportRuns = 2
+	filter, _ := docmost.NewSpaceFilter([]string{"slug:engineering"}, nil)
+	reportPath := filepath.Join(cfg.OutputDir, "Engineering", syncReportFile)
+
+	readReport := func() *syncReport {
+		t.Helper()
+		data, err := os.ReadFile(reportPath)
+		if err != nil {
+			t.Fatalf("sync report not written: %v", err)
+		}
+		var report syncReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("invalid sync report: %v", err)
+		}
+		return &report
+	}
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("runSync failed: %v", err)
+	}
+	report := readReport()
+	if len(report.Runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(report.Runs))
+	}
+	first := report.Runs[0]
+	if len(first.Pages.Added) != 7 || first.Export != "space" {
+		t.Errorf("first run: %d pages added by %q export, want 7 by space export", len(first.Pages.Added), first.Export)
+	}
+	if len(first.Steps) == 0 {
+		t.Errorf("first run has no post-processing steps")
+	}
+
+	// Pretend the last sync saw a page that is gone now
+	store := syncstate.NewStore(cfg.StateDir)
+	state, err := store.Load(report.SpaceID)
+	if err != nil || state == nil {
+		t.Fatalf("sync state not saved: %v", err)
+	}
+	state.Pages["ghost"] = syncstate.PageState{Title: "Ghost", UpdatedAt: "2024-01-01T00:00:00Z"}
+	if err := store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("second runSync failed: %v", err)
+	}
+	report = readReport()
+	if len(report.Runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(report.Runs))
+	}
+	pages := report.Runs[0].Pages
+	if len(pages.Added) != 0 || len(pages.Removed) != 1 || pages.Removed[0].ID != "ghost" {
+		t.Errorf("second run: added %v, removed %v, want only ghost removed", pages.Added, pages.Removed)
+	}
+
+	// Only the last SyncReportRuns runs are kept
+	cfg.FullSync = true
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("third runSync failed: %v", err)
+	}
+	report = readReport()
+	if len(report.Runs) != 2 {
+		t.Fatalf("expected 2 runs to be kept, got %d", len(report.Runs))
+	}
+	pages = report.Runs[0].Pages
+	if len(pages.Added)+len(pages.Removed)+len(pages.Moved)+len(pages.Reordered)+len(pages.Retitled)+len(pages.Updated) != 0 ||
+		report.Runs[1].StartedAt.Equal(first.StartedAt) {
+		t.Errorf("expected the newest run first and the oldest dropped")
+	}
+}
+
+// TestRunSync_ReportsPatchedFiles tests that a run patching changed pages into the saved
+// export reports the files of the whole export and the re-exported files separately
+func TestRunSync_ReportsPatchedFiles(t *testing.T) {
+	cfg, src, _ := newTestSync(t)
+	cfg.SyncReportRuns = 2
+	filter, _ := docmost.NewSpaceFilter([]string{"slug:engineering"}, nil)
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("runSync failed: %v", err)
+	}
+
+	// Pretend the first page was updated since the last sync
+	store := syncstate.NewStore(cfg.StateDir)
+	reportPath := filepath.Join(cfg.OutputDir, "Engineering", syncReportFile)
+	var report syncReport
+	if data, err := os.ReadFile(reportPath); err != nil || json.Unmarshal(data, &report) != nil {
+		t.Fatalf("sync report not written: %v", err)
+	}
+	metaPath := filepath.Join(store.RawDir(report.SpaceID), "_metadata.json")
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		t.Fatalf("raw export not saved: %v", err)
+	}
+	var meta docmost.SpaceMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	meta.Pages[0].UpdatedAt = "2000-01-01T00:00:00Z"
+	if data, err = json.Marshal(&meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := store.Load(report.SpaceID)
+	if err != nil || state == nil {
+		t.Fatalf("sync state not saved: %v", err)
+	}
+	page := state.Pages[meta.Pages[0].ID]
+	page.UpdatedAt = "2000-01-01T00:00:00Z"
+	state.Pages[meta.Pages[0].ID] = page
+	if err := store.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("second runSync failed: %v", err)
+	}
+	report = syncReport{}
+	if data, err := os.ReadFile(reportPath); err != nil || json.Unmarshal(data, &report) != nil {
+		t.Fatalf("sync report not written: %v", err)
+	}
+	full, patched := report.Runs[1], report.Runs[0]
+	if patched.Export != "pages" {
+		t.Fatalf("second run used a %q export, want pages", patched.Export)
+	}
+	if patched.Files != full.Files {
+		t.Errorf("patched run reports %d files, want the %d files of the whole export", patched.Files, full.Files)
+	}
+	if patched.UpdatedFiles == 0 || patched.UpdatedFiles >= patched.Files {
+		t.Errorf("patched run reports %d updated of %d files", patched.UpdatedFiles, patched.Files)
+	}
+}
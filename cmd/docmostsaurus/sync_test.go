@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jung/doc2git/internal/config"
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/fakedocmost"
+	"github.com/jung/doc2git/internal/health"
+	"github.com/jung/doc2git/internal/source"
+)
+
+// newTestSync starts a fake Docmost on the shared fixtures and returns a config and source for it
+func newTestSync(t *testing.T, opts ...fakedocmost.Option) (*config.Config, source.Source, *fakedocmost.Server) {
+	t.Helper()
+
+	server, fake := fakedocmost.NewTestServer(t, "../../internal/fakedocmost/testdata", opts...)
+	output := t.TempDir()
+	cfg := &config.Config{
+		DocmostBaseURL:          server.URL,
+		DocmostEmail:            fakedocmost.DefaultEmail,
+		DocmostPassword:         fakedocmost.DefaultPassword,
+		DocmostMaxRetries:       2,
+		DocmostRetryBaseDelay:   time.Millisecond,
+		DocmostRetryMaxDelay:    5 * time.Millisecond,
+		DocmostCrawlConcurrency: 2,
+		SyncConcurrency:         2,
+		OutputDir:               output,
+		StateDir:                filepath.Join(output, ".docmostsaurus"),
+	}
+
+	src, err := newSource(cfg)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	return cfg, src, fake
+}
+
+// TestRunSync_EndToEnd tests a sync against the fake Docmost: the included spaces are exported,
+// the excluded one is not, and an unchanged space is not exported again
+func TestRunSync_EndToEnd(t *testing.T) {
+	cfg, src, fake := newTestSync(t)
+	filter, _ := docmost.NewSpaceFilter(nil, []string{"slug:hr"})
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("runSync failed: %v", err)
+	}
+
+	spaceDir := filepath.Join(cfg.OutputDir, "Engineering")
+	data, err := os.ReadFile(filepath.Join(spaceDir, "_metadata.json"))
+	if err != nil {
+		t.Fatalf("metadata not written: %v", err)
+	}
+	var meta docmost.SpaceMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("invalid metadata: %v", err)
+	}
+	if meta.TotalPages != 7 {
+		t.Errorf("expected 7 pages, got %d", meta.TotalPages)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "HR Internal")); !os.IsNotExist(err) {
+		t.Errorf("excluded space was exported")
+	}
+
+	// Nothing changed, so the second run does not download the space again
+	exports := fake.Requests("/api/spaces/export")
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("second runSync failed: %v", err)
+	}
+	if n := fake.Requests("/api/spaces/export"); n != exports {
+		t.Errorf("unchanged space was exported again (%d exports, before %d)", n, exports)
+	}
+}
+
+// TestRunSync_SidebarWithoutTimestamps tests that an unchanged space is skipped, and never
+// exported page by page, when sidebar listings leave out updatedAt
+func TestRunSync_SidebarWithoutTimestamps(t *testing.T) {
+	cfg, src, fake := newTestSync(t, fakedocmost.WithoutSidebarTimestamps())
+	filter, _ := docmost.NewSpaceFilter([]string{"slug:engineering"}, nil)
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("runSync failed: %v", err)
+	}
+
+	// The capability probe also requests a page export, so only the second run is counted
+	pageExports := fake.Requests("/api/pages/export")
+	spaceExports := fake.Requests("/api/spaces/export")
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("second runSync failed: %v", err)
+	}
+	if n := fake.Requests("/api/pages/export") - pageExports; n != 0 {
+		t.Errorf("expected no page exports, got %d", n)
+	}
+	if n := fake.Requests("/api/spaces/export") - spaceExports; n != 0 {
+		t.Errorf("unchanged space was exported again (%d exports)", n)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "Engineering", "_metadata.json")); err != nil {
+		t.Errorf("space not exported: %v", err)
+	}
+}
+
+// TestRunSync_FailedExportKeepsPreviousOutput tests that a failed export leaves the output of the last sync in place
+func TestRunSync_FailedExportKeepsPreviousOutput(t *testing.T) {
+	cfg, src, fake := newTestSync(t)
+	filter, _ := docmost.NewSpaceFilter([]string{"slug:engineering"}, nil)
+
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("runSync failed: %v", err)
+	}
+
+	cfg.FullSync = true
+	fake.Inject(fakedocmost.Fault{Path: "/api/spaces/export", Truncate: true})
+	if err := runSync(context.Background(), cfg, src, filter); err == nil {
+		t.Fatal("expected truncated export to fail the sync")
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "Engineering", "_metadata.json")); err != nil {
+		t.Errorf("previous output was not kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutputDir, "Engineering_temp")); !os.IsNotExist(err) {
+		t.Errorf("temp directory left behind")
+	}
+}
+
+// TestRunSync_ReplaysRecordedBundle tests that replaying a recorded bundle without the server produces
+// the same output as the recorded run
+func TestRunSync_ReplaysRecordedBundle(t *testing.T) {
+	cfg, _, _ := newTestSync(t)
+	filter, _ := docmost.NewSpaceFilter([]string{"slug:engineering"}, nil)
+
+	cfg.RecordDir = filepath.Join(t.TempDir(), "bundle")
+	cfg.FullSync = true
+	src, err := newSource(cfg)
+	if err != nil {
+		t.Fatalf("failed to create recording source: %v", err)
+	}
+	if err := runSync(context.Background(), cfg, src, filter); err != nil {
+		t.Fatalf("recorded runSync failed: %v", err)
+	}
+	recorded := readTree(t, cfg.OutputDir)
+
+	// The replay needs neither the server nor credentials
+	replayOutput := t.TempDir()
+	replayCfg := *cfg
+	replayCfg.RecordDir = ""
+	replayCfg.ReplayDir = cfg.RecordDir
+	replayCfg.DocmostBaseURL = "http://docmost.invalid"
+	replayCfg.DocmostEmail, replayCfg.DocmostPassword = "", ""
+	replayCfg.OutputDir = replayOutput
+	replayCfg.StateDir = filepath.Join(replayOutput, ".docmostsaurus")
+	src, err = newSource(&replayCfg)
+	if err != nil {
+		t.Fatalf("failed to create replay source: %v", err)
+	}
+	if err := runSync(context.Background(), &replayCfg, src, filter); err != nil {
+		t.Fatalf("replayed runSync failed: %v", err)
+	}
+	replayed := readTree(t, replayOutput)
+
+	if len(recorded) == 0 || len(replayed) != len(recorded) {
+		t.Fatalf("replay wrote %d files, recording wrote %d", len(replayed), len(recorded))
+	}
+	for name, content := range recorded {
+		if replayed[name] != content {
+			t.Errorf("%s differs between recorded and replayed run", name)
+		}
+	}
+}
+
+// readTree returns the exported markdown files under dir by relative path
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(p) != ".md" {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		files[rel] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", dir, err)
+	}
+	return files
+}
+
+// TestRunSync_LocalSource tests syncing a local directory source with a tree.json manifest
+func TestRunSync_LocalSource(t *testing.T) {
+	output := t.TempDir()
+	cfg := &config.Config{
+		SourceType:      config.SourceLocal,
+		SourceDir:       "../../internal/source/testdata",
+		SyncConcurrency: 1,
+		OutputDir:       output,
+		StateDir:        filepath.Join(output, ".docmostsaurus"),
+	}
+	src, err := newSource(cfg)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+
+	if err := runSync(context.Background(), cfg, src, nil); err != nil {
+		t.Fatalf("runSync failed: %v", err)
+	}
+
+	files := readTree(t, filepath.Join(output, "Handbook"))
+	if len(files) != 4 {
+		t.Errorf("expected 4 pages, got %v", files)
+	}
+	for name, content := range files {
+		if !strings.HasPrefix(content, "---\ntitle: ") {
+			t.Errorf("%s has no frontmatter", name)
+		}
+	}
+}
+
+// TestRunTargets_IndependentSources tests that a source failing to log in neither stops nor affects the
+// other sources, and that each source reports its own health
+func TestRunTargets_IndependentSources(t *testing.T) {
+	server, _ := fakedocmost.NewTestServer(t, "../../internal/fakedocmost/testdata")
+	output := t.TempDir()
+	cfg := &config.Config{
+		SyncConcurrency: 1,
+		OutputDir:       output,
+		StateDir:        filepath.Join(output, ".docmostsaurus"),
+		Sources: []config.SourceConfig{
+			{Name: "engineering", BaseURL: server.URL, Email: fakedocmost.DefaultEmail, Password: fakedocmost.DefaultPassword, SpaceInclude: []string{"slug:engineering"}},
+			{Name: "support", BaseURL: server.URL, Email: fakedocmost.DefaultEmail, Password: "wrong"},
+			{Name: "handbook", Type: config.SourceLocal, Dir: "../../internal/source/testdata", OutputDir: "docs/handbook"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	targets, err := newSyncTargets(cfg)
+	if err != nil {
+		t.Fatalf("failed to create targets: %v", err)
+	}
+
+	checker := health.NewChecker(0)
+	if err := runTargets(context.Background(), targets, checker); err == nil {
+		t.Error("expected the failing source to be reported")
+	}
+
+	if _, err := os.Stat(filepath.Join(output, "engineering", "Engineering", "_metadata.json")); err != nil {
+		t.Errorf("engineering source not exported: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "docs", "handbook", "Handbook", "_metadata.json")); err != nil {
+		t.Errorf("handbook source not exported: %v", err)
+	}
+
+	sources := checker.GetStatus().Sources
+	if sources["engineering"].Status != "healthy" || sources["handbook"].Status != "healthy" {
+		t.Errorf("expected healthy sources, got %+v", sources)
+	}
+	if support := sources["support"]; support.Status != "degraded" || support.ErrorKind != health.ErrorKindAuth {
+		t.Errorf("expected support to fail authentication, got %+v", support)
+	}
+	if sources["engineering"].Session == nil || sources["handbook"].Session != nil {
+		t.Errorf("expected a session only for the Docmost source")
+	}
+}
+
+// TestPipelineSet_SpaceOverrides tests that per-space post-processing overrides select the pipeline of
+// matching spaces and that other spaces get the base pipeline
+func TestPipelineSet_SpaceOverrides(t *testing.T) {
+	cfg := &config.Config{
+		PostprocessDisable: []string{"wrap-raw-html"},
+		PostprocessSpaces: []config.PostprocessOverride{
+			{Spaces: []string{"slug:raw-*"}, Steps: []string{"wrap-placeholders"}},
+			{Spaces: []string{"name:Engineering"}, Disable: []string{"romanize"}},
+		},
+	}
+	ps, err := newPipelineSet(cfg)
+	if err != nil {
+		t.Fatalf("newPipelineSet failed: %v", err)
+	}
+
+	has := func(space docmost.Space, step string) bool {
+		for _, name := range ps.forSpace(space).StepNames() {
+			if name == step {
+				return true
+			}
+		}
+		return false
+	}
+
+	if got := ps.forSpace(docmost.Space{Slug: "raw-notes"}).StepNames(); len(got) != 1 || got[0] != "wrap-placeholders" {
+		t.Errorf("expected the step list of the override, got %v", got)
+	}
+	engineering := docmost.Space{Name: "Engineering", Slug: "eng"}
+	if has(engineering, "romanize") || has(engineering, "wrap-raw-html") || !has(engineering, "wrap-placeholders") {
+		t.Errorf("expected the override to disable steps in addition to POSTPROCESS_DISABLE, got %v", ps.forSpace(engineering).StepNames())
+	}
+	if other := (docmost.Space{Name: "Other", Slug: "other"}); has(other, "wrap-raw-html") || !has(other, "romanize") {
+		t.Errorf("unexpected base pipeline %v", ps.forSpace(other).StepNames())
+	}
+
+	cfg.PostprocessSteps = []string{"no-such-step"}
+	if _, err := newPipelineSet(cfg); err == nil {
+		t.Error("expected an error for an unknown step")
+	}
+}
@@ -0,0 +1,77 @@
+// Command fakedocmost runs a fake Docmost API server backed by a fixture directory,
+// for developing and testing docmostsaurus without a Docmost instance.
+//
+// Example:
+//
+//	go run ./cmd/fakedocmost -fixtures ./internal/fakedocmost/testdata -fault /api/spaces/export:truncate:1
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jung/doc2git/internal/fakedocmost"
+)
+
+// faultFlags collects repeated -fault flags
+type faultFlags []fakedocmost.Fault
+
+func (f *faultFlags) String() string {
+	return ""
+}
+
+func (f *faultFlags) Set(value string) error {
+	fault, err := fakedocmost.ParseFault(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, fault)
+	return nil
+}
+
+func main() {
+	addr := flag.String("addr", ":3000", "Listen address")
+	fixtures := flag.String("fixtures", "./internal/fakedocmost/testdata", "Fixture directory with one subdirectory per space")
+	email := flag.String("email", fakedocmost.DefaultEmail, "Accepted login email")
+	password := flag.String("password", fakedocmost.DefaultPassword, "Accepted login password")
+	apiToken := flag.String("api-token", "", "Accepted API token (Authorization: Bearer)")
+	version := flag.String("version", fakedocmost.DefaultVersion, "Version reported by /api/version (empty disables the endpoint)")
+	without := flag.String("without", "", "Comma-separated endpoints answered as unknown routes, e.g. /api/pages/export")
+	var faults faultFlags
+	flag.Var(&faults, "fault", "Inject a fault, <path>:<status|slow=<duration>|truncate>[:<times>] (repeatable)")
+	flag.Parse()
+
+	spaces, err := fakedocmost.LoadFixtures(*fixtures)
+	if err != nil {
+		log.Fatalf("Error loading fixtures: %v", err)
+	}
+
+	opts := []fakedocmost.Option{
+		fakedocmost.WithCredentials(*email, *password),
+		fakedocmost.WithFaults(faults...),
+		fakedocmost.WithVersion(*version),
+	}
+	if *without != "" {
+		opts = append(opts, fakedocmost.WithoutEndpoints(strings.Split(*without, ",")...))
+	}
+	if *apiToken != "" {
+		opts = append(opts, fakedocmost.WithAPIToken(*apiToken))
+	}
+	server := fakedocmost.New(spaces, opts...)
+
+	names := make([]string, 0, len(spaces))
+	for _, space := range spaces {
+		names = append(names, space.Name)
+	}
+	log.Printf("Serving %d spaces (%s) from %s on %s", len(spaces), strings.Join(names, ", "), *fixtures, *addr)
+	log.Printf("Login: %s / %s", *email, *password)
+	for _, fault := range faults {
+		log.Printf("Fault: %+v", fault)
+	}
+
+	if err := http.ListenAndServe(*addr, server); err != nil {
+		log.Fatalf("Server error: %v", err)
+	}
+}
@@ -2,4 +2,4 @@ module github.com/jung/doc2git
 
 go 1.21
 
-require github.com/suapapa/go_hangul v1.2.1 // indirect
+require github.com/suapapa/go_hangul v1.2.1
@@ -1,20 +1,92 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
+	// Where content is read from: SourceDocmost (default) or SourceLocal
+	SourceType string
+	SourceDir  string // collection directory of a local source
+
 	// Docmost configuration
 	DocmostBaseURL  string
 	DocmostEmail    string
 	DocmostPassword string
 
+	// Pre-issued Docmost credentials, used instead of email/password when set
+	DocmostAPIToken   string
+	DocmostAuthCookie string
+
+	// Docmost connection: per-request time limit (0 disables it), TLS and proxy settings
+	DocmostTimeout        time.Duration
+	DocmostCAFile         string // PEM CA bundle trusted in addition to the system roots
+	DocmostClientCertFile string // PEM client certificate for mutual TLS
+	DocmostClientKeyFile  string // PEM key of DocmostClientCertFile
+	DocmostProxyURL       string // empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+
+	// DocmostSkipVersionCheck accepts Docmost releases older than the oldest supported one
+	DocmostSkipVersionCheck bool
+
+	// Docmost request retry and rate limiting
+	DocmostMaxRetries     int
+	DocmostRetryBaseDelay time.Duration
+	DocmostRetryMaxDelay  time.Duration
+	DocmostRateLimit      float64 // requests per second, 0 means unlimited
+
+	// Number of child page listings in flight while building a space's page tree
+	DocmostCrawlConcurrency int
+
+	// Export archive limits (0 disables a limit)
+	ZipMaxTotalSize        int64 // bytes
+	ZipMaxFiles            int
+	ZipMaxCompressionRatio float64
+
 	// Sync settings
-	SyncInterval time.Duration
-	OutputDir    string
+	SyncInterval    time.Duration
+	SyncTimeout     time.Duration // 0 means a sync run is only bounded by shutdown
+	SyncConcurrency int           // number of spaces synced in parallel
+	OutputDir       string
+	StateDir        string // where per-space sync state is kept (default: DefaultStateDir(OutputDir))
+	FullSync        bool   // ignore saved sync state and resync every space
+	SyncReportRuns  int    // runs kept in the _sync-report.json of each space; 0 disables the report
+
+	// DryRun exports and post-processes spaces into a scratch directory and reports how the
+	// output would change instead of changing it; the plan is also written to DryRunReport as JSON if set
+	DryRun       bool
+	DryRunReport string
+
+	// Space selection rules ("slug:<glob>", "name:<glob>", "id:<id>" or a glob matching slug or name)
+	SpaceInclude         []string
+	SpaceExclude         []string
+	RemoveExcludedSpaces bool // delete output left behind by spaces that are now excluded
+
+	// RootPage is the ID or slug ID of a page whose subtree is exported instead of its whole space
+	RootPage string
+
+	// API traffic bundles: RecordDir saves every Docmost response of a run, ReplayDir serves
+	// a run from a recorded bundle instead of contacting Docmost
+	RecordDir string
+	ReplayDir string
+
+	// Post-processing pipeline: PostprocessSteps replaces the default step order,
+	// PostprocessDisable removes steps from it, and PostprocessSpaces (POSTPROCESS_FILE)
+	// changes the pipeline of single spaces; the first matching override applies
+	PostprocessSteps   []string
+	PostprocessDisable []string
+	PostprocessSpaces  []PostprocessOverride
+
+	// Named sources from SOURCES_FILE, synced one after another in place of the single
+	// source configured above (see ForSource)
+	Sources []SourceConfig
 
 	// HTTP server settings
 	HTTPPort string
@@ -31,17 +103,84 @@ type Config struct {
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	cfg := &Config{
+		SourceType:      getEnv("SOURCE_TYPE", SourceDocmost),
+		SourceDir:       getEnv("SOURCE_DIR", ""),
 		DocmostBaseURL:  getEnv("DOCMOST_BASE_URL", "http://localhost:3000"),
 		DocmostEmail:    getEnv("DOCMOST_EMAIL", ""),
 		DocmostPassword: getEnv("DOCMOST_PASSWORD", ""),
 		OutputDir:       getEnv("OUTPUT_DIR", "./output"),
-		HTTPPort:    getEnv("HTTP_PORT", ":8080"),
+		StateDir:        getEnv("STATE_DIR", ""),
+		HTTPPort:        getEnv("HTTP_PORT", ":8080"),
 		GitRepoPath:     getEnv("GIT_REPO_PATH", "./docusaurus-docs"),
 		GitBranch:       getEnv("GIT_BRANCH", "main"),
 		AutoPush:        getEnv("AUTO_PUSH", "false") == "true",
 		GitRemoteURL:    getEnv("GIT_REMOTE_URL", ""),
 		GitUsername:     getEnv("GIT_USERNAME", ""),
 		GitPassword:     getEnv("GIT_PASSWORD", ""),
+
+		DocmostTimeout:        getEnvDuration("DOCMOST_TIMEOUT", 120*time.Second),
+		DocmostCAFile:         getEnv("DOCMOST_CA_FILE", ""),
+		DocmostClientCertFile: getEnv("DOCMOST_CLIENT_CERT_FILE", ""),
+		DocmostClientKeyFile:  getEnv("DOCMOST_CLIENT_KEY_FILE", ""),
+		DocmostProxyURL:       getEnv("DOCMOST_PROXY_URL", ""),
+
+		DocmostSkipVersionCheck: getEnv("DOCMOST_SKIP_VERSION_CHECK", "false") == "true",
+
+		DocmostMaxRetries:     getEnvInt("DOCMOST_MAX_RETRIES", 3),
+		DocmostRetryBaseDelay: getEnvDuration("DOCMOST_RETRY_BASE_DELAY", time.Second),
+		DocmostRetryMaxDelay:  getEnvDuration("DOCMOST_RETRY_MAX_DELAY", 30*time.Second),
+		DocmostRateLimit:      getEnvFloat("DOCMOST_RATE_LIMIT", 0),
+
+		DocmostCrawlConcurrency: getEnvInt("METADATA_CONCURRENCY", 4),
+
+		SyncConcurrency: getEnvInt("SYNC_CONCURRENCY", 1),
+		SyncReportRuns:  getEnvInt("SYNC_REPORT_RUNS", 10),
+
+		ZipMaxTotalSize:        int64(getEnvInt("ZIP_MAX_TOTAL_SIZE_MB", 10240)) << 20,
+		ZipMaxFiles:            getEnvInt("ZIP_MAX_FILES", 100000),
+		ZipMaxCompressionRatio: getEnvFloat("ZIP_MAX_COMPRESSION_RATIO", 200),
+
+		SpaceInclude:         splitList(os.Getenv("SPACE_INCLUDE")),
+		SpaceExclude:         splitList(os.Getenv("SPACE_EXCLUDE")),
+		RemoveExcludedSpaces: getEnv("REMOVE_EXCLUDED_SPACES", "false") == "true",
+		RootPage:             strings.TrimSpace(os.Getenv("ROOT_PAGE")),
+
+		RecordDir: getEnv("RECORD_DIR", ""),
+		ReplayDir: getEnv("REPLAY_DIR", ""),
+
+		PostprocessSteps:   splitList(os.Getenv("POSTPROCESS_STEPS")),
+		PostprocessDisable: splitList(os.Getenv("POSTPROCESS_DISABLE")),
+	}
+
+	// Pre-issued credentials can be given directly or read from a file (e.g. a mounted secret)
+	var err error
+	if cfg.DocmostAPIToken, err = getEnvOrFile("DOCMOST_API_TOKEN"); err != nil {
+		return nil, err
+	}
+	if cfg.DocmostAuthCookie, err = getEnvOrFile("DOCMOST_AUTH_COOKIE"); err != nil {
+		return nil, err
+	}
+
+	// Rules from SPACE_FILTER_FILE are added to the ones given in the environment
+	if path := os.Getenv("SPACE_FILTER_FILE"); path != "" {
+		include, exclude, err := loadSpaceFilterFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg.SpaceInclude = append(cfg.SpaceInclude, include...)
+		cfg.SpaceExclude = append(cfg.SpaceExclude, exclude...)
+	}
+
+	if path := os.Getenv("POSTPROCESS_FILE"); path != "" {
+		if cfg.PostprocessSpaces, err = loadPostprocessFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := os.Getenv("SOURCES_FILE"); path != "" {
+		if cfg.Sources, err = loadSourcesFile(path); err != nil {
+			return nil, err
+		}
 	}
 
 	// Parse sync interval
@@ -57,14 +196,54 @@ func Load() (*Config, error) {
 		cfg.SyncInterval = interval
 	}
 
+	// Parse per-run timeout (SYNC_TIMEOUT). Invalid values disable the timeout.
+	if timeoutStr := os.Getenv("SYNC_TIMEOUT"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil && timeout > 0 {
+			cfg.SyncTimeout = timeout
+		}
+	}
+
 	return cfg, nil
 }
 
-// Validate checks if required configuration is present
+// Validate checks if required configuration is present.
+// Exactly one credential style is required: an API token, an auth cookie, or email and password.
+// Replaying a recorded bundle or reading a local source needs no credentials.
 func (c *Config) Validate() error {
+	switch c.SourceType {
+	case SourceDocmost, "":
+	case SourceLocal:
+		if c.SourceDir == "" {
+			return ErrMissingSourceDir
+		}
+		return nil
+	default:
+		return ErrUnknownSourceType
+	}
+	if c.RecordDir != "" && c.ReplayDir != "" {
+		return ErrConflictingBundleModes
+	}
+	if len(c.Sources) > 0 {
+		return c.validateSources()
+	}
+	if c.ReplayDir != "" {
+		return nil
+	}
 	if c.DocmostBaseURL == "" {
 		return ErrMissingBaseURL
 	}
+	if (c.DocmostClientCertFile == "") != (c.DocmostClientKeyFile == "") {
+		return ErrIncompleteClientCert
+	}
+	if c.DocmostAPIToken != "" && c.DocmostAuthCookie != "" {
+		return ErrConflictingCredentials
+	}
+	if c.DocmostAPIToken != "" || c.DocmostAuthCookie != "" {
+		return nil
+	}
+	if c.DocmostEmail == "" && c.DocmostPassword == "" {
+		return ErrMissingCredentials
+	}
 	if c.DocmostEmail == "" {
 		return ErrMissingEmail
 	}
@@ -74,6 +253,22 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// DefaultStateDir returns the sync state directory used when STATE_DIR is not set.
+// It lies in the user cache directory, outside outputDir, so saved raw exports are never
+// published with the output. Each output directory gets its own state directory.
+func DefaultStateDir(outputDir string) string {
+	if abs, err := filepath.Abs(outputDir); err == nil {
+		outputDir = abs
+	}
+	sum := sha256.Sum256([]byte(outputDir))
+
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "docmostsaurus", hex.EncodeToString(sum[:8]))
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -81,6 +276,94 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvOrFile reads key from the environment, or from the file named by key+"_FILE".
+// Surrounding whitespace (such as a trailing newline in the file) is trimmed.
+func getEnvOrFile(key string) (string, error) {
+	if value := os.Getenv(key); value != "" {
+		return strings.TrimSpace(value), nil
+	}
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadSpaceFilterFile reads space rules from a file with one "include <rule>" or
+// "exclude <rule>" per line. Blank lines and lines starting with "#" are ignored.
+func loadSpaceFilterFile(path string) (include, exclude []string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read SPACE_FILTER_FILE: %w", err)
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		action, rule, _ := strings.Cut(line, " ")
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			return nil, nil, fmt.Errorf("SPACE_FILTER_FILE line %d: missing rule", i+1)
+		}
+		switch action {
+		case "include":
+			include = append(include, rule)
+		case "exclude":
+			exclude = append(exclude, rule)
+		default:
+			return nil, nil, fmt.Errorf("SPACE_FILTER_FILE line %d: expected include or exclude, got %q", i+1, action)
+		}
+	}
+	return include, exclude, nil
+}
+
+// getEnvInt reads a non-negative integer, falling back to defaultValue when unset or invalid
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvFloat reads a non-negative number, falling back to defaultValue when unset or invalid
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvDuration reads a non-negative duration (e.g. 500ms, 2s), falling back to defaultValue when unset or invalid
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// Source types
+const (
+	SourceDocmost = "docmost"
+	SourceLocal   = "local"
+)
+
 // Custom errors
 type ConfigError string
 
@@ -92,4 +375,12 @@ const (
 	ErrMissingBaseURL  ConfigError = "DOCMOST_BASE_URL is required"
 	ErrMissingEmail    ConfigError = "DOCMOST_EMAIL is required"
 	ErrMissingPassword ConfigError = "DOCMOST_PASSWORD is required"
+
+	ErrMissingCredentials     ConfigError = "DOCMOST_EMAIL and DOCMOST_PASSWORD, DOCMOST_API_TOKEN or DOCMOST_AUTH_COOKIE is required"
+	ErrConflictingCredentials ConfigError = "only one of DOCMOST_API_TOKEN and DOCMOST_AUTH_COOKIE may be set"
+	ErrConflictingBundleModes ConfigError = "only one of RECORD_DIR and REPLAY_DIR may be set"
+	ErrIncompleteClientCert   ConfigError = "DOCMOST_CLIENT_CERT_FILE and DOCMOST_CLIENT_KEY_FILE must be set together"
+
+	ErrUnknownSourceType ConfigError = "SOURCE_TYPE must be docmost or local"
+	ErrMissingSourceDir  ConfigError = "SOURCE_DIR is required when SOURCE_TYPE is local"
 )
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDefaultStateDir tests that the default state directory lies outside the output
+// and is the same for every run with the same output
+func TestDefaultStateDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	output := t.TempDir()
+
+	dir := DefaultStateDir(output)
+	if rel, err := filepath.Rel(output, dir); err != nil || !strings.HasPrefix(rel, "..") {
+		t.Errorf("state directory %s is inside the output %s", dir, output)
+	}
+	if again := DefaultStateDir(output); again != dir {
+		t.Errorf("state directory changed between runs: %s, %s", dir, again)
+	}
+	if other := DefaultStateDir(filepath.Join(output, "other")); other == dir {
+		t.Errorf("different outputs share the state directory %s", dir)
+	}
+}
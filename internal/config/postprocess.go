@@ -0,0 +1,35 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// PostprocessOverride changes the post-processing pipeline of the spaces matching Spaces.
+// Steps, if set, replaces the pipeline; otherwise Disable removes steps from the
+// pipeline given by POSTPROCESS_STEPS and POSTPROCESS_DISABLE.
+type PostprocessOverride struct {
+	Spaces  []string `json:"spaces"` // space rules, as in SPACE_INCLUDE
+	Steps   []string `json:"steps"`
+	Disable []string `json:"disable"`
+}
+
+// loadPostprocessFile reads a JSON array of per-space pipeline overrides
+func loadPostprocessFile(path string) ([]PostprocessOverride, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read POSTPROCESS_FILE: %w", err)
+	}
+
+	var overrides []PostprocessOverride
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("invalid POSTPROCESS_FILE: %w", err)
+	}
+	for i, o := range overrides {
+		if len(o.Spaces) == 0 {
+			return nil, fmt.Errorf("POSTPROCESS_FILE entry %d: spaces is required", i+1)
+		}
+	}
+	return overrides, nil
+}
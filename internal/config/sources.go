@@ -0,0 +1,231 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SourceConfig describes one named source in SOURCES_FILE, such as a separate Docmost
+// instance. Settings not listed here (retries, limits, concurrency, ...) are shared
+// by all sources and come from the environment.
+type SourceConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // SourceDocmost (default) or SourceLocal
+
+	// Docmost connection; string values may reference environment variables as ${VAR}
+	BaseURL    string `json:"baseUrl"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	APIToken   string `json:"apiToken"`
+	AuthCookie string `json:"authCookie"`
+
+	// Files holding a secret, e.g. a mounted Docker secret, used when the value itself is not set
+	PasswordFile   string `json:"passwordFile"`
+	APITokenFile   string `json:"apiTokenFile"`
+	AuthCookieFile string `json:"authCookieFile"`
+
+	// TLS and proxy settings; when empty, the DOCMOST_* settings of the environment are used
+	CAFile         string `json:"caFile"`
+	ClientCertFile string `json:"clientCertFile"`
+	ClientKeyFile  string `json:"clientKeyFile"`
+	ProxyURL       string `json:"proxyUrl"`
+
+	// Dir is the collection directory of a local source
+	Dir string `json:"dir"`
+
+	SpaceInclude []string `json:"spaceInclude"`
+	SpaceExclude []string `json:"spaceExclude"`
+	RootPage     string   `json:"rootPage"`
+
+	// OutputDir is the subdirectory of OUTPUT_DIR the source is exported to (default: Name)
+	OutputDir string `json:"outputDir"`
+}
+
+// sourceNamePattern restricts source names to ones usable as directory and file names
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// envRefPattern matches a ${VAR} reference to an environment variable
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// loadSourcesFile reads a JSON array of sources. Secrets given as files are read, and
+// ${VAR} references in connection settings are expanded. A password, token or cookie is
+// only expanded if it is exactly ${VAR}, so literal secrets may contain "$".
+func loadSourcesFile(path string) ([]SourceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SOURCES_FILE: %w", err)
+	}
+
+	var sources []SourceConfig
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("invalid SOURCES_FILE: %w", err)
+	}
+
+	for i := range sources {
+		sc := &sources[i]
+		for _, value := range []*string{&sc.BaseURL, &sc.Email, &sc.ProxyURL, &sc.Dir} {
+			if *value, err = expandEnvRefs(*value); err != nil {
+				return nil, fmt.Errorf("source %s: %w", sc.Name, err)
+			}
+		}
+		for _, value := range []*string{&sc.Password, &sc.APIToken, &sc.AuthCookie} {
+			if *value, err = expandSecretRef(*value); err != nil {
+				return nil, fmt.Errorf("source %s: %w", sc.Name, err)
+			}
+		}
+
+		secrets := []struct {
+			value *string
+			file  string
+		}{
+			{&sc.Password, sc.PasswordFile},
+			{&sc.APIToken, sc.APITokenFile},
+			{&sc.AuthCookie, sc.AuthCookieFile},
+		}
+		for _, secret := range secrets {
+			if *secret.value != "" || secret.file == "" {
+				continue
+			}
+			data, err := os.ReadFile(secret.file)
+			if err != nil {
+				return nil, fmt.Errorf("source %s: %w", sc.Name, err)
+			}
+			*secret.value = strings.TrimSpace(string(data))
+		}
+	}
+	return sources, nil
+}
+
+// expandEnvRefs replaces the ${VAR} references in value with the environment variables.
+// Other uses of "$" are kept; a reference to an unset variable is an error.
+func expandEnvRefs(value string) (string, error) {
+	var unset string
+	expanded := envRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envRefPattern.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok && unset == "" {
+			unset = name
+		}
+		return v
+	})
+	if unset != "" {
+		return "", fmt.Errorf("environment variable %s is not set", unset)
+	}
+	return strings.TrimSpace(expanded), nil
+}
+
+// expandSecretRef returns the environment variable if secret is exactly ${VAR}, and
+// secret itself otherwise
+func expandSecretRef(secret string) (string, error) {
+	secret = strings.TrimSpace(secret)
+	if m := envRefPattern.FindStringSubmatch(secret); m == nil || m[0] != secret {
+		return secret, nil
+	}
+	return expandEnvRefs(secret)
+}
+
+// ForSource returns the configuration for syncing the named source sc: the shared
+// settings of c with the connection, space selection and output of sc. The output and
+// state directories, as well as record and replay bundles, get a subdirectory per source,
+// and a dry-run report gets the source name appended to its file name.
+func (c *Config) ForSource(sc SourceConfig) *Config {
+	cfg := *c
+	cfg.Sources = nil
+
+	cfg.SourceType = sc.Type
+	if cfg.SourceType == "" {
+		cfg.SourceType = SourceDocmost
+	}
+	cfg.SourceDir = sc.Dir
+
+	cfg.DocmostBaseURL = sc.BaseURL
+	cfg.DocmostEmail = sc.Email
+	cfg.DocmostPassword = sc.Password
+	cfg.DocmostAPIToken = sc.APIToken
+	cfg.DocmostAuthCookie = sc.AuthCookie
+
+	if sc.CAFile != "" {
+		cfg.DocmostCAFile = sc.CAFile
+	}
+	if sc.ClientCertFile != "" || sc.ClientKeyFile != "" {
+		cfg.DocmostClientCertFile = sc.ClientCertFile
+		cfg.DocmostClientKeyFile = sc.ClientKeyFile
+	}
+	if sc.ProxyURL != "" {
+		cfg.DocmostProxyURL = sc.ProxyURL
+	}
+
+	cfg.SpaceInclude = sc.SpaceInclude
+	cfg.SpaceExclude = sc.SpaceExclude
+	cfg.RootPage = sc.RootPage
+
+	outputDir := sc.OutputDir
+	if outputDir == "" {
+		outputDir = sc.Name
+	}
+	cfg.OutputDir = filepath.Join(c.OutputDir, outputDir)
+	if c.StateDir != "" {
+		cfg.StateDir = filepath.Join(c.StateDir, sc.Name)
+	}
+	if c.RecordDir != "" {
+		cfg.RecordDir = filepath.Join(c.RecordDir, sc.Name)
+	}
+	if c.ReplayDir != "" {
+		cfg.ReplayDir = filepath.Join(c.ReplayDir, sc.Name)
+	}
+	if c.DryRunReport != "" {
+		ext := filepath.Ext(c.DryRunReport)
+		cfg.DryRunReport = strings.TrimSuffix(c.DryRunReport, ext) + "-" + sc.Name + ext
+	}
+	return &cfg
+}
+
+// validateSources checks that every source is complete and that no two sources share
+// a name or an output directory. Output directories may not be nested in one another
+// either, since a source writes and removes whole space directories below its own.
+func (c *Config) validateSources() error {
+	names := make(map[string]bool)
+	outputs := make(map[string]string)
+
+	for i, sc := range c.Sources {
+		if !sourceNamePattern.MatchString(sc.Name) {
+			return fmt.Errorf("source %d: name %q must consist of letters, digits, '.', '_' or '-'", i+1, sc.Name)
+		}
+		if names[sc.Name] {
+			return fmt.Errorf("source %s: name is used more than once", sc.Name)
+		}
+		names[sc.Name] = true
+
+		if sc.OutputDir != "" {
+			clean := filepath.Clean(sc.OutputDir)
+			if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+				return fmt.Errorf("source %s: outputDir must be a subdirectory of OUTPUT_DIR", sc.Name)
+			}
+		}
+		output := c.ForSource(sc).OutputDir
+		for otherOutput, other := range outputs {
+			if output == otherOutput {
+				return fmt.Errorf("source %s: output directory %s is already used by source %s", sc.Name, output, other)
+			}
+			if isSubdir(output, otherOutput) || isSubdir(otherOutput, output) {
+				return fmt.Errorf("source %s: output directory %s overlaps %s of source %s", sc.Name, output, otherOutput, other)
+			}
+		}
+		outputs[output] = sc.Name
+
+		if err := c.ForSource(sc).Validate(); err != nil {
+			return fmt.Errorf("source %s: %w", sc.Name, err)
+		}
+	}
+	return nil
+}
+
+// isSubdir reports whether dir lies below parent; both are cleaned paths
+func isSubdir(dir, parent string) bool {
+	rel, err := filepath.Rel(parent, dir)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLoadSourcesFile tests that sources are read with their secrets and environment references
+func TestLoadSourcesFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "token"), []byte("secret-token\n"), 0600)
+	path := filepath.Join(dir, "sources.json")
+	os.WriteFile(path, []byte(`[
+		{"name": "eng", "baseUrl": "${TEST_DOCMOST_URL}", "email": "me@example.com", "password": "${TEST_DOCMOST_PASSWORD}"},
+		{"name": "support", "baseUrl": "https://support.example.com", "apiTokenFile": "`+filepath.Join(dir, "token")+`", "outputDir": "help"}
+	]`), 0644)
+	t.Setenv("TEST_DOCMOST_URL", "https://eng.example.com")
+	t.Setenv("TEST_DOCMOST_PASSWORD", "hunter2")
+
+	sources, err := loadSourcesFile(path)
+	if err != nil {
+		t.Fatalf("loadSourcesFile failed: %v", err)
+	}
+	if sources[0].BaseURL != "https://eng.example.com" || sources[0].Password != "hunter2" {
+		t.Errorf("environment variables not expanded: %+v", sources[0])
+	}
+	if sources[1].APIToken != "secret-token" {
+		t.Errorf("token file not read: %q", sources[1].APIToken)
+	}
+
+	cfg := &Config{OutputDir: "out", StateDir: "state", SyncConcurrency: 2, Sources: sources}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	support := cfg.ForSource(sources[1])
+	if support.OutputDir != filepath.Join("out", "help") || support.StateDir != filepath.Join("state", "support") {
+		t.Errorf("unexpected directories: output %s, state %s", support.OutputDir, support.StateDir)
+	}
+	if support.DocmostEmail != "" || support.SyncConcurrency != 2 {
+		t.Errorf("source settings not applied: %+v", support)
+	}
+}
+
+// TestLoadSourcesFile_LiteralSecrets tests that secrets containing "$" are kept as they are
+// and that references to unset variables are rejected
+func TestLoadSourcesFile_LiteralSecrets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sources.json")
+	os.WriteFile(path, []byte(`[
+		{"name": "eng", "baseUrl": "https://eng.example.com", "email": "me@example.com", "password": "pa$$word"},
+		{"name": "support", "baseUrl": "https://support.example.com", "apiToken": "s3cr$t!${HOME}"}
+	]`), 0644)
+
+	sources, err := loadSourcesFile(path)
+	if err != nil {
+		t.Fatalf("loadSourcesFile failed: %v", err)
+	}
+	if sources[0].Password != "pa$$word" {
+		t.Errorf("password changed to %q", sources[0].Password)
+	}
+	if sources[1].APIToken != "s3cr$t!${HOME}" {
+		t.Errorf("token changed to %q", sources[1].APIToken)
+	}
+
+	os.WriteFile(path, []byte(`[{"name": "eng", "baseUrl": "https://eng.example.com", "apiToken": "${TEST_UNSET_DOCMOST_TOKEN}"}]`), 0644)
+	if _, err := loadSourcesFile(path); err == nil {
+		t.Errorf("expected an error for an unset variable")
+	}
+}
+
+// TestValidateSources tests that incomplete or conflicting sources are rejected
+func TestValidateSources(t *testing.T) {
+	valid := SourceConfig{Name: "eng", BaseURL: "https://eng.example.com", APIToken: "token"}
+	tests := map[string][]SourceConfig{
+		"duplicate name":      {valid, valid},
+		"invalid name":        {{Name: "../eng", BaseURL: "https://eng.example.com", APIToken: "token"}},
+		"shared output":       {valid, {Name: "support", BaseURL: "https://support.example.com", APIToken: "token", OutputDir: "eng"}},
+		"nested output":       {valid, {Name: "support", BaseURL: "https://support.example.com", APIToken: "token", OutputDir: "eng/support"}},
+		"enclosing output":    {{Name: "eng", BaseURL: "https://eng.example.com", APIToken: "token", OutputDir: "docs/eng"}, {Name: "docs", BaseURL: "https://docs.example.com", APIToken: "token"}},
+		"escaping output":     {{Name: "eng", BaseURL: "https://eng.example.com", APIToken: "token", OutputDir: "../elsewhere"}},
+		"missing credentials": {{Name: "eng", BaseURL: "https://eng.example.com"}},
+		"local without dir":   {{Name: "wiki", Type: SourceLocal}},
+		"unknown source type": {{Name: "wiki", Type: "confluence"}},
+	}
+
+	for name, sources := range tests {
+		cfg := &Config{OutputDir: "out", Sources: sources}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package docmost
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsafeArchive is returned when an export archive contains entries that would escape
+// the target directory or exceeds the configured ExtractLimits
+var ErrUnsafeArchive = errors.New("unsafe export archive")
+
+// ExtractLimits bounds the resources an export archive may consume when extracted.
+// A zero value disables the corresponding limit.
+type ExtractLimits struct {
+	MaxTotalSize        int64   // total uncompressed size of all entries in bytes
+	MaxFiles            int     // number of file entries
+	MaxCompressionRatio float64 // total uncompressed size divided by the archive size
+}
+
+// DefaultExtractLimits returns the limits used when none are configured.
+// They are far above what real Docmost spaces produce while still stopping zip bombs.
+func DefaultExtractLimits() ExtractLimits {
+	return ExtractLimits{
+		MaxTotalSize:        10 << 30, // 10 GiB
+		MaxFiles:            100000,
+		MaxCompressionRatio: 200,
+	}
+}
+
+// ExtractArchive safely extracts a space export ZIP at zipPath into destDir, for exports
+// obtained outside of the client such as ones downloaded from the Docmost UI.
+// It returns the extracted file paths relative to destDir.
+func ExtractArchive(zipPath, destDir string, limits ExtractLimits) ([]string, error) {
+	return extractZip(zipPath, destDir, limits)
+}
+
+// extractZip extracts the files of the ZIP archive at zipPath into destDir.
+// Every entry is validated before anything is written: absolute paths, paths containing "..",
+// symlinks and archives exceeding limits are rejected with ErrUnsafeArchive.
+// Entries are copied to disk one at a time. It returns the extracted paths relative to destDir.
+func extractZip(zipPath, destDir string, limits ExtractLimits) ([]string, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer reader.Close()
+
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat zip: %w", err)
+	}
+
+	// Validate all entries up front so that an unsafe path is rejected before anything is written
+	var entries []*zip.File
+	var names []string
+	var declaredSize uint64
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		name, err := safeEntryName(file)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, file)
+		names = append(names, name)
+		declaredSize += file.UncompressedSize64
+	}
+
+	if limits.MaxFiles > 0 && len(entries) > limits.MaxFiles {
+		return nil, fmt.Errorf("%w: %d files exceed the limit of %d", ErrUnsafeArchive, len(entries), limits.MaxFiles)
+	}
+	if err := checkExtractedSize(declaredSize, info.Size(), limits); err != nil {
+		return nil, err
+	}
+
+	// Headers can lie about sizes, so the limits are enforced again on the bytes actually written
+	var written uint64
+	for i, file := range entries {
+		n, err := extractZipEntry(file, filepath.Join(destDir, filepath.FromSlash(names[i])), remainingSize(written, limits))
+		written += uint64(n)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkExtractedSize(written, info.Size(), limits); err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+// safeEntryName returns the normalized "/"-separated name of a ZIP entry,
+// or an error if the entry is a symlink or its path is not confined to the target directory
+func safeEntryName(file *zip.File) (string, error) {
+	if file.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("%w: entry %q is a symlink", ErrUnsafeArchive, file.Name)
+	}
+
+	// Normalize path separators; archives created on Windows may use backslashes
+	name := strings.ReplaceAll(file.Name, "\\", "/")
+
+	// Reject both "/etc/x" and Windows drive paths like "C:/x"
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", fmt.Errorf("%w: entry %q has an absolute path", ErrUnsafeArchive, file.Name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: entry %q escapes the export directory", ErrUnsafeArchive, file.Name)
+		}
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == "." {
+		return "", fmt.Errorf("%w: entry %q has an empty path", ErrUnsafeArchive, file.Name)
+	}
+	return cleaned, nil
+}
+
+// checkExtractedSize checks an uncompressed size against the total-size and compression-ratio limits
+func checkExtractedSize(size uint64, archiveSize int64, limits ExtractLimits) error {
+	if limits.MaxTotalSize > 0 && size > uint64(limits.MaxTotalSize) {
+		return fmt.Errorf("%w: uncompressed size exceeds the limit of %d bytes", ErrUnsafeArchive, limits.MaxTotalSize)
+	}
+	if limits.MaxCompressionRatio > 0 && archiveSize > 0 {
+		ratio := float64(size) / float64(archiveSize)
+		if ratio > limits.MaxCompressionRatio {
+			return fmt.Errorf("%w: compression ratio %.0f exceeds the limit of %.0f", ErrUnsafeArchive, ratio, limits.MaxCompressionRatio)
+		}
+	}
+	return nil
+}
+
+// remainingSize returns how many more bytes may be written under MaxTotalSize, or -1 if unlimited
+func remainingSize(written uint64, limits ExtractLimits) int64 {
+	if limits.MaxTotalSize <= 0 {
+		return -1
+	}
+	if written >= uint64(limits.MaxTotalSize) {
+		return 0
+	}
+	return limits.MaxTotalSize - int64(written)
+}
+
+// extractZipEntry writes a single ZIP entry to target, creating parent directories as needed.
+// At most maxBytes+1 bytes are written (maxBytes < 0 means unlimited) so that the caller
+// can detect an entry larger than allowed. It returns the number of bytes written.
+func extractZipEntry(file *zip.File, target string, maxBytes int64) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory for %s: %w", file.Name, err)
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file %s: %w", file.Name, err)
+	}
+
+	var src io.Reader = rc
+	if maxBytes >= 0 {
+		src = io.LimitReader(rc, maxBytes+1)
+	}
+
+	n, err := io.Copy(out, src)
+	if err != nil {
+		out.Close()
+		return n, fmt.Errorf("failed to read file %s: %w", file.Name, err)
+	}
+	if err := out.Close(); err != nil {
+		return n, fmt.Errorf("failed to write file %s: %w", file.Name, err)
+	}
+	return n, nil
+}
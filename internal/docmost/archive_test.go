@@ -0,0 +1,153 @@
+package docmost
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestZip creates a ZIP file in dir with the given entries (name -> content)
+func writeTestZip(t *testing.T, dir string, entries map[string]string) string {
+	t.Helper()
+
+	zipPath := filepath.Join(dir, "export.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("failed to create zip: %v", err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range entries {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		entry.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to finish zip: %v", err)
+	}
+	return zipPath
+}
+
+// TestExtractZip_WritesEntriesToDisk tests that entries are extracted into nested directories
+func TestExtractZip_WritesEntriesToDisk(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := writeTestZip(t, tempDir, map[string]string{
+		"Guide.md":                "# Guide",
+		"Guide/Install.md":        "# Install",
+		"Guide/files/diagram.png": "png",
+	})
+
+	destDir := filepath.Join(tempDir, "space_temp")
+	files, err := extractZip(zipPath, destDir, DefaultExtractLimits())
+	if err != nil {
+		t.Fatalf("extractZip failed: %v", err)
+	}
+
+	if len(files) != 3 {
+		t.Errorf("expected 3 extracted files, got %v", files)
+	}
+	for _, name := range files {
+		if _, err := os.Stat(filepath.Join(destDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected %s to exist on disk: %v", name, err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "Guide", "Install.md"))
+	if err != nil || string(content) != "# Install" {
+		t.Errorf("unexpected content of Guide/Install.md: %q (%v)", content, err)
+	}
+}
+
+// TestExtractZip_RejectsUnsafePaths tests that entries escaping the target directory are rejected
+func TestExtractZip_RejectsUnsafePaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+	}{
+		{"parent traversal", "../../etc/passwd"},
+		{"nested traversal", "docs/../../outside.md"},
+		{"backslash traversal", "..\\outside.md"},
+		{"absolute path", "/etc/cron.d/job"},
+		{"windows drive", "C:/Windows/evil.md"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			zipPath := writeTestZip(t, tempDir, map[string]string{
+				"ok.md":  "# ok",
+				tc.entry: "evil",
+			})
+
+			destDir := filepath.Join(tempDir, "space_temp")
+			_, err := extractZip(zipPath, destDir, DefaultExtractLimits())
+			if !errors.Is(err, ErrUnsafeArchive) {
+				t.Fatalf("expected ErrUnsafeArchive, got %v", err)
+			}
+			if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+				t.Errorf("expected nothing to be extracted when an entry is unsafe")
+			}
+		})
+	}
+}
+
+// TestExtractZip_RejectsSymlinks tests that symlink entries are rejected
+func TestExtractZip_RejectsSymlinks(t *testing.T) {
+	tempDir := t.TempDir()
+	zipPath := filepath.Join(tempDir, "export.zip")
+	f, _ := os.Create(zipPath)
+	w := zip.NewWriter(f)
+	header := &zip.FileHeader{Name: "link.md"}
+	header.SetMode(os.ModeSymlink | 0777)
+	entry, _ := w.CreateHeader(header)
+	entry.Write([]byte("/etc/passwd"))
+	w.Close()
+	f.Close()
+
+	_, err := extractZip(zipPath, filepath.Join(tempDir, "out"), DefaultExtractLimits())
+	if !errors.Is(err, ErrUnsafeArchive) {
+		t.Errorf("expected ErrUnsafeArchive, got %v", err)
+	}
+}
+
+// TestExtractZip_Limits tests the file-count, total-size and compression-ratio limits
+func TestExtractZip_Limits(t *testing.T) {
+	entries := map[string]string{
+		"a.md": strings.Repeat("a", 100000),
+		"b.md": "# b",
+		"c.md": "# c",
+	}
+
+	tests := []struct {
+		name   string
+		limits ExtractLimits
+		unsafe bool
+	}{
+		{"within limits", ExtractLimits{MaxTotalSize: 1 << 20, MaxFiles: 10, MaxCompressionRatio: 1000}, false},
+		{"unlimited", ExtractLimits{}, false},
+		{"too many files", ExtractLimits{MaxFiles: 2}, true},
+		{"too large", ExtractLimits{MaxTotalSize: 50000}, true},
+		{"compression ratio", ExtractLimits{MaxCompressionRatio: 10}, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			zipPath := writeTestZip(t, tempDir, entries)
+
+			_, err := extractZip(zipPath, filepath.Join(tempDir, "out"), tc.limits)
+			if tc.unsafe && !errors.Is(err, ErrUnsafeArchive) {
+				t.Errorf("expected ErrUnsafeArchive, got %v", err)
+			}
+			if !tc.unsafe && err != nil {
+				t.Errorf("expected extraction to succeed, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package docmost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// A bundle is a directory holding the Docmost API traffic of a run: bundle.json describes
+// the recording, and every response is stored as NNNNNN.json (request and response metadata)
+// plus NNNNNN.body (the response body, e.g. JSON or an export ZIP).
+// Login requests are recorded without their body, and no cookies or tokens are stored.
+//
+// A recorder writes every run into a new bundle named after its start time below the
+// record directory, keeping the last keptBundles of them. Replaying such a directory
+// replays its latest bundle.
+
+// keptBundles is the number of bundles a recorder keeps in its record directory
+const keptBundles = 5
+
+// bundleTimeFormat names bundles so that lexical order is recording order
+const bundleTimeFormat = "20060102T150405.000Z"
+
+// bundleManifest is the bundle.json of a bundle
+type bundleManifest struct {
+	BaseURL    string    `json:"baseUrl"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// bundleEntry describes one recorded request and its response
+type bundleEntry struct {
+	Seq         int             `json:"seq"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	RequestBody json.RawMessage `json:"requestBody,omitempty"`
+	Status      int             `json:"status"`
+	Header      http.Header     `json:"header,omitempty"`
+	BodyFile    string          `json:"bodyFile"`
+}
+
+// recordedHeaders are the response headers kept in a bundle
+var recordedHeaders = []string{"Content-Type", "Content-Disposition", "Retry-After"}
+
+// WithRecorder saves every API request and response of the client into a bundle below
+// dir, so that the run can be replayed later with WithReplay. See StartRecording.
+func WithRecorder(dir string) Option {
+	return func(c *Client) {
+		c.recordDir = dir
+	}
+}
+
+// WithReplay serves every API request of the client from the bundle recorded with
+// WithRecorder, without contacting Docmost. dir is either a bundle or a record directory,
+// whose latest bundle is used.
+func WithReplay(dir string) Option {
+	return func(c *Client) {
+		c.replayDir = dir
+	}
+}
+
+// bundleRequestKey identifies a request for replay: its method, path and request body.
+// The login body holds credentials, so it is never recorded or compared.
+func bundleRequestKey(method, path string, body []byte) (string, json.RawMessage) {
+	if path == "/api/auth/login" || len(body) == 0 {
+		return method + " " + path, nil
+	}
+
+	// Normalize the JSON so that key order does not matter
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if normalized, err := json.Marshal(v); err == nil {
+			body = normalized
+		}
+	}
+	return method + " " + path + " " + string(body), json.RawMessage(body)
+}
+
+// readRequestBody returns the body of req and restores it for sending
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// StartRecording begins a new bundle for the requests that follow, so every run gets
+// a bundle of its own. Without WithRecorder it does nothing. Requests made before the
+// first call are not recorded, so commands such as check leave the record directory alone.
+func (c *Client) StartRecording() error {
+	t, ok := c.httpClient.Transport.(*recordingTransport)
+	if !ok {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.startBundle()
+}
+
+// recordingTransport sends requests through next and writes every response into the
+// current bundle below root
+type recordingTransport struct {
+	next    http.RoundTripper
+	root    string
+	baseURL string
+
+	mu  sync.Mutex
+	dir string // current bundle, empty until StartRecording is called
+	seq int
+}
+
+// newRecordingTransport creates a transport recording into bundles below root, which is
+// created with the first bundle
+func newRecordingTransport(root, baseURL string, next http.RoundTripper) *recordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{next: next, root: root, baseURL: baseURL}
+}
+
+// startBundle creates a new, empty bundle and removes the oldest ones beyond keptBundles.
+// t.mu must be held.
+func (t *recordingTransport) startBundle() error {
+	if err := os.MkdirAll(t.root, 0755); err != nil {
+		return fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	now := time.Now().UTC()
+	name := now.Format(bundleTimeFormat)
+	dir := filepath.Join(t.root, name)
+	// Mkdir fails for an existing directory, so a bundle never mixes the entries of two runs
+	for i := 2; ; i++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create bundle: %w", err)
+		}
+		dir = filepath.Join(t.root, fmt.Sprintf("%s-%d", name, i))
+	}
+	manifest, _ := json.MarshalIndent(bundleManifest{BaseURL: t.baseURL, RecordedAt: now}, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "bundle.json"), manifest, 0644); err != nil {
+		return fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+	t.dir = dir
+	t.seq = 0
+
+	bundles, err := listBundles(t.root)
+	if err != nil {
+		return err
+	}
+	for len(bundles) > keptBundles {
+		if err := os.RemoveAll(bundles[0]); err != nil {
+			return fmt.Errorf("failed to remove old bundle: %w", err)
+		}
+		bundles = bundles[1:]
+	}
+	return nil
+}
+
+// listBundles returns the bundles directly below root, oldest first
+func listBundles(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bundles: %w", err)
+	}
+	var bundles []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, "bundle.json")); err == nil {
+			bundles = append(bundles, dir)
+		}
+	}
+	// ReadDir sorts by name, which is recording order
+	return bundles, nil
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	if t.dir == "" {
+		t.mu.Unlock()
+		return resp, nil
+	}
+	t.seq++
+	seq, dir := t.seq, t.dir
+	t.mu.Unlock()
+
+	_, recordedBody := bundleRequestKey(req.Method, req.URL.Path, body)
+	entry := bundleEntry{
+		Seq:         seq,
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		RequestBody: recordedBody,
+		Status:      resp.StatusCode,
+		Header:      http.Header{},
+		BodyFile:    fmt.Sprintf("%06d.body", seq),
+	}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			entry.Header.Set(name, value)
+		}
+	}
+
+	// The entry is written right away, so a response whose body is never closed is still replayable
+	data, _ := json.MarshalIndent(entry, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%06d.json", seq)), data, 0644); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to record response: %w", err)
+	}
+	bodyFile, err := os.Create(filepath.Join(dir, entry.BodyFile))
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to record response: %w", err)
+	}
+	resp.Body = &recordingBody{body: resp.Body, file: bodyFile}
+	return resp, nil
+}
+
+// recordingBody copies a response body into the bundle as it is read. A failure to save
+// the body is returned from Read or Close, so an incomplete recording fails the request.
+type recordingBody struct {
+	body io.ReadCloser
+	file *os.File
+	err  error // first error writing file
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.body.Read(p)
+	if n > 0 {
+		if _, werr := b.file.Write(p[:n]); werr != nil {
+			b.err = fmt.Errorf("failed to record response: %w", werr)
+			return n, b.err
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	// Keep the complete body even if the caller stopped reading early
+	if b.err == nil {
+		if _, err := io.Copy(b.file, b.body); err != nil {
+			b.err = fmt.Errorf("failed to record response: %w", err)
+		}
+	}
+	if err := b.file.Close(); err != nil && b.err == nil {
+		b.err = fmt.Errorf("failed to record response: %w", err)
+	}
+	if b.err != nil {
+		fmt.Printf("Warning: %v, the bundle is incomplete\n", b.err)
+	}
+
+	if err := b.body.Close(); err != nil {
+		return err
+	}
+	return b.err
+}
+
+// replayTransport answers requests with the responses recorded in a bundle.
+// Responses to the same request are replayed in recording order; once they are used up,
+// the last one is repeated.
+type replayTransport struct {
+	dir string
+
+	mu        sync.Mutex
+	responses map[string][]bundleEntry
+}
+
+// newReplayTransport loads the entries of the bundle in dir, or of the latest bundle
+// below dir if dir is a record directory
+func newReplayTransport(dir string) (*replayTransport, error) {
+	if _, err := os.Stat(filepath.Join(dir, "bundle.json")); err != nil {
+		bundles, listErr := listBundles(dir)
+		if listErr != nil || len(bundles) == 0 {
+			return nil, fmt.Errorf("not a recorded bundle: %w", err)
+		}
+		dir = bundles[len(bundles)-1]
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "[0-9]*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]bundleEntry, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle entry: %w", err)
+		}
+		var entry bundleEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse bundle entry %s: %w", filepath.Base(name), err)
+		}
+		entries = append(entries, entry)
+	}
+
+	// Glob returns names in lexical order, which is recording order
+	t := &replayTransport{dir: dir, responses: make(map[string][]bundleEntry)}
+	for _, entry := range entries {
+		key, _ := bundleRequestKey(entry.Method, entry.Path, entry.RequestBody)
+		t.responses[key] = append(t.responses[key], entry)
+	}
+	return t, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := bundleRequestKey(req.Method, req.URL.Path, body)
+
+	t.mu.Lock()
+	queue := t.responses[key]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		// A run recorded with an API token or cookie never logged in; any login is fine for replay
+		if req.URL.Path == "/api/auth/login" {
+			return &http.Response{
+				Status:     "200 OK",
+				StatusCode: http.StatusOK,
+				Proto:      "HTTP/1.1",
+				ProtoMajor: 1,
+				ProtoMinor: 1,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"data":{},"success":true,"status":200}`))),
+				Request:    req,
+			}, nil
+		}
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.Path)
+	}
+	entry := queue[0]
+	if len(queue) > 1 {
+		t.responses[key] = queue[1:]
+	}
+	t.mu.Unlock()
+
+	f, err := os.Open(filepath.Join(t.dir, entry.BodyFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recorded response: %w", err)
+	}
+
+	header := entry.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode: entry.Status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       f,
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package docmost
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRecorder_BundlePerRun tests that every run is recorded into a bundle of its own,
+// that old bundles are pruned and that replaying the record directory uses the latest one
+func TestRecorder_BundlePerRun(t *testing.T) {
+	run := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/auth/login" {
+			return
+		}
+		w.Write([]byte(`{"data":{"items":[{"id":"space-` + string(rune('0'+run)) + `"}],"meta":{}},"success":true,"status":200}`))
+	}))
+	defer server.Close()
+
+	root := filepath.Join(t.TempDir(), "bundles")
+	client, err := NewClient(server.URL, "user@example.com", "secret", WithRecorder(root))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	for run = 1; run <= keptBundles+1; run++ {
+		if err := client.StartRecording(); err != nil {
+			t.Fatalf("run %d: StartRecording failed: %v", run, err)
+		}
+		if _, err := client.ListSpaces(context.Background()); err != nil {
+			t.Fatalf("run %d: ListSpaces failed: %v", run, err)
+		}
+	}
+
+	bundles, err := listBundles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != keptBundles {
+		t.Fatalf("expected %d bundles, got %d", keptBundles, len(bundles))
+	}
+	for _, bundle := range bundles {
+		// Every bundle starts numbering at 1 and holds only the listing of its own run
+		names, _ := filepath.Glob(filepath.Join(bundle, "[0-9]*.json"))
+		if len(names) != 1 || filepath.Base(names[0]) != "000001.json" {
+			t.Errorf("bundle %s holds %v", filepath.Base(bundle), names)
+		}
+	}
+
+	replay, err := NewClient("http://docmost.invalid", "user@example.com", "secret", WithReplay(root))
+	if err != nil {
+		t.Fatalf("failed to create replay client: %v", err)
+	}
+	spaces, err := replay.ListSpaces(context.Background())
+	if err != nil {
+		t.Fatalf("replayed ListSpaces failed: %v", err)
+	}
+	if want := "space-" + string(rune('0'+keptBundles+1)); len(spaces) != 1 || spaces[0].ID != want {
+		t.Errorf("expected the latest bundle with %s, got %+v", want, spaces)
+	}
+}
+
+// TestRecordingBody_WriteError tests that a response that cannot be saved fails instead
+// of leaving a silently truncated bundle
+func TestRecordingBody_WriteError(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "000001.body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close() // writes to a closed file fail
+
+	body := &recordingBody{body: io.NopCloser(strings.NewReader("response")), file: file}
+	if _, err := io.ReadAll(body); err == nil {
+		t.Errorf("expected Read to fail")
+	}
+	if err := body.Close(); err == nil {
+		t.Errorf("expected Close to fail")
+	}
+}
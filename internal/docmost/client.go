@@ -2,23 +2,139 @@ package docmost
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
-	"path/filepath"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Client is the Docmost API client
 type Client struct {
-	baseURL    string
-	email      string
-	password   string
-	httpClient *http.Client
-	loggedIn   bool
+	baseURL     string
+	email       string
+	password    string
+	httpClient  *http.Client
+	retryPolicy RetryPolicy
+	limiter     *rateLimiter
+
+	// crawlConcurrency is the number of workers fetching child pages while building a page tree
+	crawlConcurrency int
+
+	// extractLimits bounds what an export archive may unpack to
+	extractLimits ExtractLimits
+
+	// Pre-issued credentials; when set, email and password are not used
+	apiToken   string
+	authCookie string
+
+	// TLS and proxy settings; nil uses http.DefaultTransport
+	transportConfig *TransportConfig
+
+	// skipVersionCheck lets Probe accept servers older than MinSupportedVersion
+	skipVersionCheck bool
+
+	// Bundle directories for recording or replaying API traffic (see WithRecorder and WithReplay)
+	recordDir string
+	replayDir string
+
+	// loginMu serializes logins so that concurrent requests hitting an expired session re-login only once
+	loginMu sync.Mutex
+
+	// mu guards the session state below
+	mu            sync.Mutex
+	loggedIn      bool
+	generation    int // incremented on every successful login
+	lastLogin     time.Time
+	lastAuthError error
+	caps          *Capabilities // set by Probe
+}
+
+// SessionState describes the authentication session of a Client
+type SessionState struct {
+	LoggedIn  bool
+	LastLogin time.Time
+	Logins    int    // successful logins, including automatic re-authentications
+	LastError string // last authentication error, cleared by a successful login
+}
+
+// AuthError is returned when Docmost rejects the credentials or the session
+type AuthError struct {
+	Op         string // "login" or the rejected endpoint
+	StatusCode int
+	Message    string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s failed with status %d: %s", e.Op, e.StatusCode, e.Message)
+}
+
+// AuthFailure marks the error as an authentication failure for packages that classify
+// errors without importing docmost (see health.Checker)
+func (e *AuthError) AuthFailure() bool {
+	return true
+}
+
+// isAuthStatus reports whether a response status means the session is not (or no longer) accepted
+func isAuthStatus(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// Option configures optional Client behaviour
+type Option func(*Client)
+
+// WithRetryPolicy sets the retry policy for idempotent requests
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithExtractLimits sets the size, file-count and compression-ratio limits for export archives
+func WithExtractLimits(limits ExtractLimits) Option {
+	return func(c *Client) {
+		c.extractLimits = limits
+	}
+}
+
+// WithCrawlConcurrency sets how many workers fetch child pages in parallel while building a page tree
+func WithCrawlConcurrency(n int) Option {
+	return func(c *Client) {
+		if n < 1 {
+			n = 1
+		}
+		c.crawlConcurrency = n
+	}
+}
+
+// WithAPIToken authenticates every request with a long-lived API token sent as a Bearer token
+// instead of logging in with email and password
+func WithAPIToken(token string) Option {
+	return func(c *Client) {
+		c.apiToken = token
+	}
+}
+
+// WithAuthCookie authenticates with an existing authToken cookie value
+// instead of logging in with email and password
+func WithAuthCookie(value string) Option {
+	return func(c *Client) {
+		c.authCookie = value
+	}
+}
+
+// WithRateLimit limits the client to rps requests per second (0 disables the limit)
+func WithRateLimit(rps float64) Option {
+	return func(c *Client) {
+		c.limiter = newRateLimiter(rps)
+	}
 }
 
 // Space represents a Docmost space
@@ -42,6 +158,10 @@ type Page struct {
 	SpaceID      string  `json:"spaceId"`
 	CreatorID    string  `json:"creatorId"`
 	HasChildren  bool    `json:"hasChildren"`
+
+	// UpdatedAt is zero when the server does not report it for sidebar pages; the crawl then
+	// reads it from the page info
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 // PageMeta represents metadata for a single page
@@ -55,6 +175,7 @@ type PageMeta struct {
 	HasChildren  bool        `json:"hasChildren"`
 	Children     []*PageMeta `json:"children,omitempty"`
 	FilePath     string      `json:"filePath,omitempty"`
+	UpdatedAt    string      `json:"updatedAt,omitempty"`
 }
 
 // SpaceMeta represents metadata for a space including page tree structure
@@ -67,6 +188,10 @@ type SpaceMeta struct {
 	UpdatedAt   string      `json:"updatedAt"`
 	Pages       []*PageMeta `json:"pages"`
 	TotalPages  int         `json:"totalPages"`
+	RootPageID  string      `json:"rootPageId,omitempty"` // set when only the subtree of this page is exported
+
+	// FileIssues lists pages whose exported file could not be identified unambiguously
+	FileIssues []FileIssue `json:"fileIssues,omitempty"`
 }
 
 // APIResponse is the generic API response wrapper
@@ -76,36 +201,34 @@ type APIResponse struct {
 	Status  int             `json:"status"`
 }
 
+// ListMeta represents the pagination metadata of a list response
+type ListMeta struct {
+	Limit       int  `json:"limit"`
+	Page        int  `json:"page"`
+	HasNextPage bool `json:"hasNextPage"`
+	HasPrevPage bool `json:"hasPrevPage"`
+}
+
 // SpaceListData represents the spaces list response data
 type SpaceListData struct {
-	Items []Space `json:"items"`
-	Meta  struct {
-		Limit       int  `json:"limit"`
-		Page        int  `json:"page"`
-		HasNextPage bool `json:"hasNextPage"`
-		HasPrevPage bool `json:"hasPrevPage"`
-	} `json:"meta"`
+	Items []Space  `json:"items"`
+	Meta  ListMeta `json:"meta"`
 }
 
 // PageListData represents the pages list response data
 type PageListData struct {
-	Items []Page `json:"items"`
-	Meta  struct {
-		Limit       int  `json:"limit"`
-		Page        int  `json:"page"`
-		HasNextPage bool `json:"hasNextPage"`
-		HasPrevPage bool `json:"hasPrevPage"`
-	} `json:"meta"`
+	Items []Page   `json:"items"`
+	Meta  ListMeta `json:"meta"`
 }
 
 // NewClient creates a new Docmost API client with cookie-based authentication
-func NewClient(baseURL, email, password string) (*Client, error) {
+func NewClient(baseURL, email, password string, opts ...Option) (*Client, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
 	}
 
-	return &Client{
+	c := &Client{
 		baseURL:  strings.TrimSuffix(baseURL, "/"),
 		email:    email,
 		password: password,
@@ -113,12 +236,58 @@ func NewClient(baseURL, email, password string) (*Client, error) {
 			Timeout: 120 * time.Second,
 			Jar:     jar,
 		},
-		loggedIn: false,
-	}, nil
+		retryPolicy:      DefaultRetryPolicy(),
+		extractLimits:    DefaultExtractLimits(),
+		crawlConcurrency: 4,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.transportConfig != nil {
+		transport, err := newTransport(*c.transportConfig)
+		if err != nil {
+			return nil, err
+		}
+		c.httpClient.Transport = transport
+	}
+
+	switch {
+	case c.replayDir != "":
+		transport, err := newReplayTransport(c.replayDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load replay bundle: %w", err)
+		}
+		c.httpClient.Transport = transport
+	case c.recordDir != "":
+		c.httpClient.Transport = newRecordingTransport(c.recordDir, c.baseURL, c.httpClient.Transport)
+	}
+
+	if c.authCookie != "" {
+		u, err := url.Parse(c.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL: %w", err)
+		}
+		jar.SetCookies(u, []*http.Cookie{{Name: "authToken", Value: c.authCookie, Path: "/"}})
+	}
+
+	return c, nil
+}
+
+// usesStaticCredentials reports whether the client authenticates with a pre-issued token or cookie.
+// Such credentials cannot be renewed by logging in again.
+func (c *Client) usesStaticCredentials() bool {
+	return c.apiToken != "" || c.authCookie != ""
 }
 
-// Login authenticates with Docmost API
-func (c *Client) Login() error {
+// Login authenticates with Docmost API.
+// With an API token or auth cookie there is nothing to exchange, so the session is simply marked as established.
+func (c *Client) Login(ctx context.Context) error {
+	if c.usesStaticCredentials() {
+		c.setSession(true, nil)
+		return nil
+	}
+
 	loginData := map[string]string{
 		"email":    c.email,
 		"password": c.password,
@@ -130,14 +299,14 @@ func (c *Client) Login() error {
 	}
 
 	url := fmt.Sprintf("%s/api/auth/login", c.baseURL)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create login request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return fmt.Errorf("login request failed: %w", err)
 	}
@@ -145,143 +314,249 @@ func (c *Client) Login() error {
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("login failed with status %d: %s", resp.StatusCode, string(respBody))
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("login failed with status %d: %s", resp.StatusCode, string(respBody))
+		}
+		authErr := &AuthError{Op: "login", StatusCode: resp.StatusCode, Message: string(respBody)}
+		c.setSession(false, authErr)
+		return authErr
 	}
 
-	c.loggedIn = true
+	c.setSession(true, nil)
 	return nil
 }
 
-// doRequest performs an authenticated HTTP request
-func (c *Client) doRequest(method, endpoint string, body io.Reader) (*http.Response, error) {
-	if !c.loggedIn {
-		if err := c.Login(); err != nil {
-			return nil, fmt.Errorf("failed to login: %w", err)
-		}
-	}
+// setSession records the outcome of a login or a rejected request
+func (c *Client) setSession(loggedIn bool, authErr error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	c.loggedIn = loggedIn
+	c.lastAuthError = authErr
+	if loggedIn {
+		c.generation++
+		c.lastLogin = time.Now()
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	return c.httpClient.Do(req)
 }
 
-// ListSpaces retrieves all accessible spaces
-func (c *Client) ListSpaces() ([]Space, error) {
-	reqBody := map[string]interface{}{
-		"limit":  100,
-		"offset": 0,
-	}
-	body, _ := json.Marshal(reqBody)
+// Session returns the current authentication session state
+func (c *Client) Session() SessionState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	resp, err := c.doRequest(http.MethodPost, "/api/spaces/", bytes.NewReader(body))
-	if err != nil {
-		return nil, err
+	state := SessionState{
+		LoggedIn:  c.loggedIn,
+		LastLogin: c.lastLogin,
+		Logins:    c.generation,
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("list spaces failed with status %d: %s", resp.StatusCode, string(respBody))
+	if c.lastAuthError != nil {
+		state.LastError = c.lastAuthError.Error()
 	}
+	return state
+}
 
-	var apiResp APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// ensureSession logs in if there is no session yet and returns the session generation
+func (c *Client) ensureSession(ctx context.Context) (int, error) {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	c.mu.Lock()
+	loggedIn, generation := c.loggedIn, c.generation
+	c.mu.Unlock()
+	if loggedIn {
+		return generation, nil
 	}
 
-	var data SpaceListData
-	if err := json.Unmarshal(apiResp.Data, &data); err != nil {
-		return nil, fmt.Errorf("failed to decode spaces data: %w", err)
+	if err := c.Login(ctx); err != nil {
+		return 0, err
 	}
 
-	return data.Items, nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation, nil
 }
 
-// ListSidebarPages retrieves all pages in a space from sidebar
-func (c *Client) ListSidebarPages(spaceID string) ([]Page, error) {
-	reqBody := map[string]interface{}{
-		"spaceId": spaceID,
+// relogin replaces the session that was current at the given generation.
+// If another request already logged in again since then, the new session is reused.
+func (c *Client) relogin(ctx context.Context, generation int) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	c.mu.Lock()
+	current := c.generation
+	c.mu.Unlock()
+	if current != generation {
+		return nil
 	}
-	body, _ := json.Marshal(reqBody)
 
-	resp, err := c.doRequest(http.MethodPost, "/api/pages/sidebar-pages", bytes.NewReader(body))
+	return c.Login(ctx)
+}
+
+// doRequest performs an authenticated HTTP request.
+// The request is bound to ctx, so cancelling ctx aborts it even while the response body is being read.
+// When the session has expired (401/403) it logs in again once and replays the request;
+// if the replay is rejected too, an *AuthError is returned.
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	generation, err := c.ensureSession(ctx)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to login: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	resp, err := c.doRequestWithRetry(ctx, method, endpoint, body)
+	if err != nil || !isAuthStatus(resp.StatusCode) {
+		return resp, err
+	}
+	if c.usesStaticCredentials() {
+		// A rejected token or cookie cannot be renewed, so replaying the request is pointless
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("list pages failed with status %d: %s", resp.StatusCode, string(respBody))
+		resp.Body.Close()
+		authErr := &AuthError{Op: endpoint, StatusCode: resp.StatusCode, Message: string(respBody)}
+		c.setSession(false, authErr)
+		return nil, authErr
 	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
 
-	var apiResp APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	fmt.Printf("Session rejected with status %d on %s, logging in again...\n", resp.StatusCode, endpoint)
+	if err := c.relogin(ctx, generation); err != nil {
+		return nil, fmt.Errorf("failed to re-authenticate: %w", err)
 	}
 
-	var data PageListData
-	if err := json.Unmarshal(apiResp.Data, &data); err != nil {
-		return nil, fmt.Errorf("failed to decode pages data: %w", err)
+	resp, err = c.doRequestWithRetry(ctx, method, endpoint, body)
+	if err != nil || !isAuthStatus(resp.StatusCode) {
+		return resp, err
 	}
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
 
-	return data.Items, nil
+	authErr := &AuthError{Op: endpoint, StatusCode: resp.StatusCode, Message: string(respBody)}
+	c.setSession(false, authErr)
+	return nil, authErr
 }
 
-// ListChildPages retrieves child pages of a specific page
-func (c *Client) ListChildPages(pageID string) ([]Page, error) {
-	reqBody := map[string]interface{}{
-		"pageId": pageID,
+// doRequestWithRetry performs a single logical request.
+// Requests to idempotent endpoints are retried on network errors and transient statuses
+// according to the client's RetryPolicy.
+func (c *Client) doRequestWithRetry(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	maxRetries := 0
+	if idempotentEndpoints[endpoint] {
+		maxRetries = c.retryPolicy.MaxRetries
 	}
-	body, _ := json.Marshal(reqBody)
 
-	resp, err := c.doRequest(http.MethodPost, "/api/pages/sidebar-pages", bytes.NewReader(body))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	url := fmt.Sprintf("%s%s", c.baseURL, endpoint)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("list child pages failed with status %d: %s", resp.StatusCode, string(respBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.send(req)
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+		if attempt >= maxRetries {
+			return resp, err
+		}
+
+		delay := c.retryPolicy.backoff(attempt+1, resp)
+		if err != nil {
+			fmt.Printf("Warning: %s %s failed: %v, retrying in %v (%d/%d)\n", method, endpoint, err, delay, attempt+1, maxRetries)
+		} else {
+			fmt.Printf("Warning: %s %s returned status %d, retrying in %v (%d/%d)\n", method, endpoint, resp.StatusCode, delay, attempt+1, maxRetries)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
 	}
+}
 
-	var apiResp APIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// send waits for the rate limiter and executes a single HTTP request
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.apiToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiToken)
 	}
+	if err := c.limiter.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(req)
+}
 
-	var data PageListData
-	if err := json.Unmarshal(apiResp.Data, &data); err != nil {
-		return nil, fmt.Errorf("failed to decode child pages data: %w", err)
+// ListSpaces retrieves all accessible spaces, following pagination until the last page
+func (c *Client) ListSpaces(ctx context.Context) ([]Space, error) {
+	return listAll(ctx, c, "list spaces", "/api/spaces/", nil, func(s Space) string { return s.ID })
+}
+
+// ListSidebarPages retrieves all root pages in a space from sidebar, following pagination until the last page
+func (c *Client) ListSidebarPages(ctx context.Context, spaceID string) ([]Page, error) {
+	params := map[string]interface{}{
+		"spaceId": spaceID,
 	}
+	return listAll(ctx, c, "list pages", "/api/pages/sidebar-pages", params, func(p Page) string { return p.ID })
+}
 
-	return data.Items, nil
+// ListChildPages retrieves child pages of a specific page, following pagination until the last page
+func (c *Client) ListChildPages(ctx context.Context, pageID string) ([]Page, error) {
+	params := map[string]interface{}{
+		"pageId": pageID,
+	}
+	return listAll(ctx, c, "list child pages", "/api/pages/sidebar-pages", params, func(p Page) string { return p.ID })
 }
 
-// GetSpaceMetadata retrieves metadata for a space including page tree structure
-func (c *Client) GetSpaceMetadata(space Space, files map[string][]byte) (*SpaceMeta, error) {
-	pages, err := c.ListSidebarPages(space.ID)
+// GetSpaceMetadata retrieves metadata for a space including page tree structure.
+// Child pages are fetched concurrently (see WithCrawlConcurrency). If any listing or
+// updatedAt lookup fails, the whole crawl is cancelled and the error is returned instead
+// of a truncated tree.
+// FilePath is left empty; see AssignFilePaths.
+func (c *Client) GetSpaceMetadata(ctx context.Context, space Space) (*SpaceMeta, error) {
+	pages, err := c.ListSidebarPages(ctx, space.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pages: %w", err)
 	}
+	return c.crawlSpace(ctx, space, pages)
+}
 
-	// Build root pages with recursive children fetch
-	var rootPages []*PageMeta
-	totalPages := 0
-	for _, p := range pages {
-		pm := c.buildPageMeta(p, files, &totalPages)
-		rootPages = append(rootPages, pm)
+// crawlSpace builds the metadata of space with pages as the top-level pages
+func (c *Client) crawlSpace(ctx context.Context, space Space, pages []Page) (*SpaceMeta, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	crawler := &pageCrawler{
+		client: c,
+		cancel: cancel,
+	}
+	crawler.cond = sync.NewCond(&crawler.mu)
+
+	// Build root pages, then their descendants, with a fixed number of workers
+	rootPages := crawler.enqueue(pages)
+	var wg sync.WaitGroup
+	for i := 0; i < c.crawlConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			crawler.work(ctx)
+		}()
+	}
+	wg.Wait()
+	if err := crawler.firstError(); err != nil {
+		return nil, err
 	}
 
-	// Sort pages by position
-	sortPagesByPosition(rootPages)
+	// A cancelled crawl yields a truncated tree, which must not be mistaken for the real one
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sortPageTree(rootPages)
 
 	return &SpaceMeta{
 		ID:          space.ID,
@@ -291,64 +566,166 @@ func (c *Client) GetSpaceMetadata(space Space, files map[string][]byte) (*SpaceM
 		CreatedAt:   space.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   space.UpdatedAt.Format(time.RFC3339),
 		Pages:       rootPages,
-		TotalPages:  totalPages,
+		TotalPages:  crawler.total,
 	}, nil
 }
 
-// buildPageMeta builds PageMeta recursively fetching children
-func (c *Client) buildPageMeta(p Page, files map[string][]byte, totalCount *int) *PageMeta {
-	*totalCount++
-
-	pm := &PageMeta{
-		ID:           p.ID,
-		SlugID:       p.SlugID,
-		Title:        p.Title,
-		Icon:         p.Icon,
-		Position:     p.Position,
-		ParentPageID: p.ParentPageID,
-		HasChildren:  p.HasChildren,
-		Children:     []*PageMeta{},
-		FilePath:     findFilePathForPage(p.Title, files),
-	}
-
-	// Recursively fetch children if page has children
-	if p.HasChildren {
-		childPages, err := c.ListChildPages(p.ID)
-		if err == nil {
-			for _, child := range childPages {
-				childMeta := c.buildPageMeta(child, files, totalCount)
-				pm.Children = append(pm.Children, childMeta)
-			}
-			sortPagesByPosition(pm.Children)
+// pageCrawler builds the page tree of a space. Pages whose children or updatedAt are
+// still to be fetched wait in a queue that a fixed number of workers drain, so the
+// requests in flight are limited however large the space is.
+type pageCrawler struct {
+	client *Client
+	cancel context.CancelFunc
+
+	// untimed is set once a page turns out to have no updatedAt at all. The space is
+	// then synced in full anyway, so the remaining lookups are skipped.
+	untimed int32
+
+	mu      sync.Mutex
+	cond    *sync.Cond  // signalled when tasks are queued or the last one finishes
+	queue   []crawlTask // pages waiting for a worker
+	pending int         // queued and running tasks
+	total   int         // pages seen so far
+	err     error
+}
+
+// crawlTask fills in meta, built from page, with its updatedAt and children
+type crawlTask struct {
+	page Page
+	meta *PageMeta
+}
+
+// enqueue creates PageMeta for pages, queues them to be completed by the workers and
+// returns them in the API order
+func (pc *pageCrawler) enqueue(pages []Page) []*PageMeta {
+	metas := make([]*PageMeta, len(pages))
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for i, p := range pages {
+		metas[i] = &PageMeta{
+			ID:           p.ID,
+			SlugID:       p.SlugID,
+			Title:        p.Title,
+			Icon:         p.Icon,
+			Position:     p.Position,
+			ParentPageID: p.ParentPageID,
+			HasChildren:  p.HasChildren,
+			Children:     []*PageMeta{},
 		}
+		pc.queue = append(pc.queue, crawlTask{page: p, meta: metas[i]})
 	}
-
-	return pm
+	pc.pending += len(pages)
+	pc.total += len(pages)
+	pc.cond.Broadcast()
+	return metas
 }
 
-// findFilePathForPage tries to find the file path for a page based on title
-func findFilePathForPage(title string, files map[string][]byte) string {
-	sanitized := SanitizeFilename(title)
-	for path := range files {
-		// Check if this is a markdown file that matches the title
-		if strings.HasSuffix(path, ".md") {
-			base := strings.TrimSuffix(filepath.Base(path), ".md")
-			if base == sanitized || base == title {
-				return path
-			}
+// work runs queued tasks until none are queued or running
+func (pc *pageCrawler) work(ctx context.Context) {
+	for {
+		task, ok := pc.next()
+		if !ok {
+			return
 		}
+		pc.complete(ctx, task)
+		pc.finish()
 	}
-	return ""
 }
 
-// sortPagesByPosition sorts pages by their position field
-func sortPagesByPosition(pages []*PageMeta) {
-	// Simple bubble sort - position is a string that can be compared lexicographically
-	for i := 0; i < len(pages); i++ {
-		for j := i + 1; j < len(pages); j++ {
-			if pages[i].Position > pages[j].Position {
-				pages[i], pages[j] = pages[j], pages[i]
-			}
+// next takes a task from the queue, waiting while other workers may still queue some.
+// It reports false once the crawl is done.
+func (pc *pageCrawler) next() (crawlTask, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for len(pc.queue) == 0 && pc.pending > 0 {
+		pc.cond.Wait()
+	}
+	if len(pc.queue) == 0 {
+		return crawlTask{}, false
+	}
+	task := pc.queue[0]
+	pc.queue = pc.queue[1:]
+	return task, true
+}
+
+// finish marks a task taken by next as done
+func (pc *pageCrawler) finish() {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.pending--
+	if pc.pending == 0 {
+		pc.cond.Broadcast()
+	}
+}
+
+// complete fetches the missing updatedAt and the children of a page.
+// Once ctx is cancelled, it returns without requests, so the queue drains quickly.
+func (pc *pageCrawler) complete(ctx context.Context, task crawlTask) {
+	p, pm := task.page, task.meta
+
+	updatedAt := p.UpdatedAt
+	if updatedAt.IsZero() && ctx.Err() == nil && atomic.LoadInt32(&pc.untimed) == 0 {
+		info, err := pc.client.GetPage(ctx, p.ID)
+		if err != nil {
+			pc.fail(fmt.Errorf("failed to get updatedAt of page %q (%s): %w", p.Title, p.ID, err))
+			return
 		}
+		// Sidebar listings of many Docmost releases leave updatedAt out, and without it
+		// a page can never be proven unchanged
+		updatedAt = info.UpdatedAt
+		if updatedAt.IsZero() {
+			atomic.StoreInt32(&pc.untimed, 1)
+		}
+	}
+	if !updatedAt.IsZero() {
+		pm.UpdatedAt = updatedAt.Format(time.RFC3339Nano)
+	}
+
+	if p.HasChildren && ctx.Err() == nil {
+		childPages, err := pc.client.ListChildPages(ctx, p.ID)
+		if err != nil {
+			pc.fail(fmt.Errorf("failed to list children of page %q (%s): %w", p.Title, p.ID, err))
+			return
+		}
+		pm.Children = pc.enqueue(childPages)
+	}
+}
+
+// fail records the first crawl error and cancels the remaining requests
+func (pc *pageCrawler) fail(err error) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.err == nil {
+		pc.err = err
+		pc.cancel()
 	}
 }
+
+// firstError returns the first error recorded by fail
+func (pc *pageCrawler) firstError() error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.err
+}
+
+// sortPageTree sorts pages and, recursively, their children by position.
+// The API order is kept for pages with equal positions, so the result is deterministic.
+func sortPageTree(pages []*PageMeta) {
+	sortPagesByPosition(pages)
+	for _, p := range pages {
+		sortPageTree(p.Children)
+	}
+}
+
+// sortPagesByPosition sorts pages by their position field.
+// Position is a string that can be compared lexicographically; the sort is stable.
+func sortPagesByPosition(pages []*PageMeta) {
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Position < pages[j].Position
+	})
+}
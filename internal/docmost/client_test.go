@@ -0,0 +1,162 @@
+package docmost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// newSessionServer starts a server that issues a new authToken cookie on every login and
+// accepts only the most recent one. If rejectAll is set, every request is rejected with 401.
+func newSessionServer(t *testing.T, rejectAll bool) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var logins int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&logins, 1)
+		http.SetCookie(w, &http.Cookie{Name: "authToken", Value: fmt.Sprintf("token-%d", n), Path: "/"})
+	})
+	mux.HandleFunc("/api/spaces/", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("authToken")
+		current := fmt.Sprintf("token-%d", atomic.LoadInt32(&logins))
+		if rejectAll || err != nil || cookie.Value != current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		data, _ := json.Marshal(SpaceListData{Items: []Space{{ID: "s1"}}})
+		json.NewEncoder(w).Encode(APIResponse{Data: data})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &logins
+}
+
+// TestDoRequest_ReauthenticatesExpiredSession tests that a 401 triggers one re-login and a replay
+func TestDoRequest_ReauthenticatesExpiredSession(t *testing.T) {
+	server, logins := newSessionServer(t, false)
+
+	client, _ := NewClient(server.URL, "user", "pass")
+	ctx := context.Background()
+	if err := client.Login(ctx); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	// Invalidate the client's session by logging in with another client
+	other, _ := NewClient(server.URL, "user", "pass")
+	other.Login(ctx)
+
+	spaces, err := client.ListSpaces(ctx)
+	if err != nil {
+		t.Fatalf("expected request to succeed after re-login, got %v", err)
+	}
+	if len(spaces) != 1 {
+		t.Errorf("expected 1 space, got %d", len(spaces))
+	}
+
+	session := client.Session()
+	if !session.LoggedIn || session.Logins != 2 || session.LastError != "" {
+		t.Errorf("unexpected session state after re-login: %+v", session)
+	}
+	if *logins != 3 {
+		t.Errorf("expected 3 logins on the server, got %d", *logins)
+	}
+}
+
+// TestDoRequest_PersistentRejectionIsAuthError tests that a rejected replay surfaces an AuthError
+func TestDoRequest_PersistentRejectionIsAuthError(t *testing.T) {
+	server, logins := newSessionServer(t, true)
+
+	client, _ := NewClient(server.URL, "user", "pass")
+	_, err := client.ListSpaces(context.Background())
+
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected AuthError, got %v", err)
+	}
+	if authErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", authErr.StatusCode)
+	}
+	if *logins != 2 {
+		t.Errorf("expected exactly one re-login (2 logins), got %d", *logins)
+	}
+
+	session := client.Session()
+	if session.LoggedIn || session.LastError == "" {
+		t.Errorf("expected session to be marked as failed, got %+v", session)
+	}
+}
+
+// TestStaticCredentials tests API token and cookie authentication without the login endpoint
+func TestStaticCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		option Option
+		accept func(r *http.Request) bool
+	}{
+		{
+			name:   "api token",
+			option: WithAPIToken("secret-token"),
+			accept: func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer secret-token" },
+		},
+		{
+			name:   "auth cookie",
+			option: WithAuthCookie("cookie-value"),
+			accept: func(r *http.Request) bool {
+				cookie, err := r.Cookie("authToken")
+				return err == nil && cookie.Value == "cookie-value"
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var logins, calls int32
+			reject := false
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&logins, 1)
+			})
+			mux.HandleFunc("/api/spaces/", func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if reject || !tc.accept(r) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				data, _ := json.Marshal(SpaceListData{Items: []Space{{ID: "s1"}}})
+				json.NewEncoder(w).Encode(APIResponse{Data: data})
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client, err := NewClient(server.URL, "", "", tc.option)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			if _, err := client.ListSpaces(context.Background()); err != nil {
+				t.Fatalf("expected request to be accepted, got %v", err)
+			}
+
+			// A revoked credential is reported without replaying the request
+			reject = true
+			_, err = client.ListSpaces(context.Background())
+			var authErr *AuthError
+			if !errors.As(err, &authErr) {
+				t.Errorf("expected AuthError, got %v", err)
+			}
+			if logins != 0 {
+				t.Errorf("expected login endpoint not to be called, got %d calls", logins)
+			}
+			if calls != 2 {
+				t.Errorf("expected 2 requests without replay, got %d", calls)
+			}
+		})
+	}
+}
@@ -1,24 +1,25 @@
 package docmost
 
 import (
-	"archive/zip"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"path/filepath"
+	"os"
 	"strings"
 )
 
-// ExportedSpace contains the exported space data
+// ExportedSpace describes a space exported to disk
 type ExportedSpace struct {
 	Space    Space
-	Files    map[string][]byte // filepath -> content (markdown files and attachments)
-	Metadata *SpaceMeta        // metadata with page tree structure
+	Dir      string     // directory the export was extracted into
+	Files    []string   // extracted file paths relative to Dir, using "/" separators (markdown files and attachments)
+	Metadata *SpaceMeta // metadata with page tree structure
 }
 
-// ExportSpaceAsZip exports an entire space as a ZIP file (markdown format)
-func (c *Client) ExportSpaceAsZip(spaceID string) ([]byte, error) {
+// ExportSpaceAsZip exports an entire space as a ZIP file (markdown format) and streams it to w.
+// It returns the number of bytes written.
+func (c *Client) ExportSpaceAsZip(ctx context.Context, spaceID string, w io.Writer) (int64, error) {
 	reqBody := map[string]interface{}{
 		"spaceId":            spaceID,
 		"format":             "markdown",
@@ -26,99 +27,77 @@ func (c *Client) ExportSpaceAsZip(spaceID string) ([]byte, error) {
 	}
 	body, _ := json.Marshal(reqBody)
 
-	resp, err := c.doRequest("POST", "/api/spaces/export", bytes.NewReader(body))
+	resp, err := c.doRequest(ctx, "POST", "/api/spaces/export", body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to export space: %w", err)
+		return 0, fmt.Errorf("failed to export space: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("export space failed with status %d: %s", resp.StatusCode, string(respBody))
+		return 0, fmt.Errorf("export space failed with status %d: %s", resp.StatusCode, string(respBody))
 	}
 
-	return io.ReadAll(resp.Body)
-}
-
-// ExportSpace exports a space and returns extracted files with metadata
-func (c *Client) ExportSpace(space Space) (*ExportedSpace, error) {
-	zipData, err := c.ExportSpaceAsZip(space.ID)
+	n, err := io.Copy(w, resp.Body)
 	if err != nil {
-		return nil, err
+		return n, fmt.Errorf("failed to download export: %w", err)
 	}
+	return n, nil
+}
 
-	files, err := extractZip(zipData)
+// ExportSpace exports a space into destDir and returns the extracted files with metadata.
+// It is DownloadSpace followed by GetSpaceMetadata and AssignFilePaths.
+func (c *Client) ExportSpace(ctx context.Context, space Space, destDir string) (*ExportedSpace, error) {
+	files, err := c.DownloadSpace(ctx, space, destDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract zip: %w", err)
+		return nil, err
 	}
 
 	// Get metadata with page tree structure
-	metadata, err := c.GetSpaceMetadata(space, files)
+	// Without a complete page tree, post-processing would drop or misplace pages,
+	// so a failed crawl fails the export and the previous output is kept
+	metadata, err := c.GetSpaceMetadata(ctx, space)
 	if err != nil {
-		// Log warning but don't fail the export
-		fmt.Printf("Warning: failed to get metadata for space %s: %v\n", space.Name, err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
 	}
+	AssignFilePaths(metadata, files)
 
 	return &ExportedSpace{
 		Space:    space,
+		Dir:      destDir,
 		Files:    files,
 		Metadata: metadata,
 	}, nil
 }
 
-// ExportAllSpaces exports all accessible spaces
-func (c *Client) ExportAllSpaces() ([]*ExportedSpace, error) {
-	spaces, err := c.ListSpaces()
+// DownloadSpace downloads the markdown export of a space and extracts it into destDir.
+// The ZIP is streamed to a file in the system temp directory and extracted entry by entry,
+// so memory usage does not grow with the size of the space and an interrupted download
+// leaves nothing next to destDir.
+// It returns the extracted file paths relative to destDir.
+func (c *Client) DownloadSpace(ctx context.Context, space Space, destDir string) ([]string, error) {
+	zipFile, err := os.CreateTemp("", "docmost-export-*.zip")
 	if err != nil {
-		return nil, fmt.Errorf("failed to list spaces: %w", err)
+		return nil, fmt.Errorf("failed to create temp file for export: %w", err)
 	}
+	defer os.Remove(zipFile.Name())
 
-	var exportedSpaces []*ExportedSpace
-	for _, space := range spaces {
-		fmt.Printf("Exporting space: %s (%s)\n", space.Name, space.ID)
-
-		exported, err := c.ExportSpace(space)
-		if err != nil {
-			fmt.Printf("Warning: failed to export space %s: %v\n", space.Name, err)
-			continue
-		}
-
-		exportedSpaces = append(exportedSpaces, exported)
-		fmt.Printf("  Exported %d files from space: %s\n", len(exported.Files), space.Name)
+	size, err := c.ExportSpaceAsZip(ctx, space.ID, zipFile)
+	if closeErr := zipFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write export: %w", closeErr)
 	}
-
-	return exportedSpaces, nil
-}
-
-// extractZip extracts files from a ZIP archive
-func extractZip(zipData []byte) (map[string][]byte, error) {
-	reader, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to open zip: %w", err)
+		return nil, err
 	}
+	fmt.Printf("  Downloaded %d bytes for space: %s\n", size, space.Name)
 
-	files := make(map[string][]byte)
-	for _, file := range reader.File {
-		if file.FileInfo().IsDir() {
-			continue
-		}
-
-		rc, err := file.Open()
-		if err != nil {
-			return nil, fmt.Errorf("failed to open file %s: %w", file.Name, err)
-		}
-
-		content, err := io.ReadAll(rc)
-		rc.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file %s: %w", file.Name, err)
-		}
-
-		// Normalize path separators
-		name := filepath.ToSlash(file.Name)
-		files[name] = content
+	files, err := extractZip(zipFile.Name(), destDir, c.extractLimits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract zip: %w", err)
 	}
-
 	return files, nil
 }
 
@@ -0,0 +1,184 @@
+package docmost
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// File issue kinds
+const (
+	// FileAmbiguous means several unclaimed files could belong to the page; the first one was used
+	FileAmbiguous = "ambiguous"
+	// FileConflict means the only matching file already belongs to another page, so the page has none
+	FileConflict = "conflict"
+)
+
+// FileIssue describes a page whose exported file could not be identified unambiguously
+type FileIssue struct {
+	PageID     string   `json:"pageId"`
+	Title      string   `json:"title"`
+	Kind       string   `json:"kind"`
+	FilePath   string   `json:"filePath,omitempty"`   // the file assigned to the page, if any
+	Candidates []string `json:"candidates,omitempty"` // the files that matched the page's title
+}
+
+// AssignFilePaths sets FilePath on every page of meta to its file in files, the paths of an
+// extracted space export.
+//
+// The export stores every page as "<title>.md" inside a folder named after its parent page,
+// so a page is first looked up at the path implied by its parent chain. If that file does not
+// exist, an unclaimed file with the page's title is used, preferring files under the parent's
+// folder. Pages are visited in tree order, so among siblings with the same title the one with
+// the lower position claims a file first. Every file is assigned to at most one page.
+//
+// Pages whose file was a guess among several candidates, or whose only matching file already
+// belongs to another page, are recorded in meta.FileIssues and returned.
+func AssignFilePaths(meta *SpaceMeta, files []string) []FileIssue {
+	m := &fileMatcher{
+		byName:  make(map[string][]string),
+		exists:  make(map[string]bool),
+		claimed: make(map[string]string),
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f, ".md") {
+			continue
+		}
+		m.exists[f] = true
+		base := strings.TrimSuffix(path.Base(f), ".md")
+		m.byName[base] = append(m.byName[base], f)
+	}
+	for _, candidates := range m.byName {
+		sort.Strings(candidates)
+	}
+
+	var assign func(pages []*PageMeta, parentDir string)
+	assign = func(pages []*PageMeta, parentDir string) {
+		for _, pm := range pages {
+			pm.FilePath = m.match(pm, parentDir)
+
+			// Children live in a folder named after the page, next to its file
+			childDir := ""
+			if pm.FilePath != "" {
+				childDir = strings.TrimSuffix(pm.FilePath, ".md")
+			}
+			assign(pm.Children, childDir)
+		}
+	}
+
+	if meta.RootPageID != "" && len(meta.Pages) > 0 && meta.Pages[0].ID == meta.RootPageID {
+		// A re-rooted subtree: the pages after the root are its children in the export
+		root := meta.Pages[0]
+		assign([]*PageMeta{root}, "")
+		rootDir := ""
+		if root.FilePath != "" {
+			rootDir = strings.TrimSuffix(root.FilePath, ".md")
+		}
+		assign(meta.Pages[1:], rootDir)
+	} else {
+		assign(meta.Pages, "")
+	}
+
+	meta.FileIssues = m.issues
+	return m.issues
+}
+
+// fileMatcher assigns export files to pages
+type fileMatcher struct {
+	byName  map[string][]string // markdown files by base name without extension
+	exists  map[string]bool
+	claimed map[string]string // file path -> page ID
+	issues  []FileIssue
+}
+
+// match finds the file of pm, whose parent's folder is parentDir ("" if unknown or top level)
+func (m *fileMatcher) match(pm *PageMeta, parentDir string) string {
+	names := []string{SanitizeFilename(pm.Title)}
+	if pm.Title != names[0] {
+		names = append(names, pm.Title)
+	}
+
+	// The path implied by the parent chain
+	for _, name := range names {
+		expected := path.Join(parentDir, name+".md")
+		if m.exists[expected] && m.claimed[expected] == "" {
+			return m.claim(expected, pm)
+		}
+	}
+
+	var candidates, free, freeUnderParent []string
+	for _, name := range names {
+		for _, f := range m.byName[name] {
+			candidates = append(candidates, f)
+			if m.claimed[f] != "" {
+				continue
+			}
+			free = append(free, f)
+			if parentDir != "" && strings.HasPrefix(f, parentDir+"/") {
+				freeUnderParent = append(freeUnderParent, f)
+			}
+		}
+	}
+
+	if len(freeUnderParent) > 0 {
+		free = freeUnderParent
+	}
+	switch {
+	case len(free) == 1:
+		return m.claim(free[0], pm)
+	case len(free) > 1:
+		m.issues = append(m.issues, FileIssue{PageID: pm.ID, Title: pm.Title, Kind: FileAmbiguous, FilePath: free[0], Candidates: candidates})
+		return m.claim(free[0], pm)
+	case len(candidates) > 0:
+		m.issues = append(m.issues, FileIssue{PageID: pm.ID, Title: pm.Title, Kind: FileConflict, Candidates: candidates})
+	}
+	return ""
+}
+
+// claim marks file as belonging to pm and returns it
+func (m *fileMatcher) claim(file string, pm *PageMeta) string {
+	m.claimed[file] = pm.ID
+	return file
+}
+
+// InferSpaceMeta builds the page tree of an export whose metadata is not available from
+// its file layout, the reverse of AssignFilePaths: every .md file is a page titled after
+// its file name, and the .md files in the folder of the same name are its children.
+// A file whose folder has no page file belongs to the nearest ancestor page, or the top level.
+// Pages are ordered by file name and identified by their file path.
+func InferSpaceMeta(name string, files []string) *SpaceMeta {
+	var mdFiles []string
+	for _, f := range files {
+		if strings.HasSuffix(f, ".md") && !strings.HasPrefix(path.Base(f), ".") {
+			mdFiles = append(mdFiles, f)
+		}
+	}
+	sort.Strings(mdFiles)
+
+	pages := make(map[string]*PageMeta, len(mdFiles))
+	for _, f := range mdFiles {
+		pages[f] = &PageMeta{ID: f, Title: strings.TrimSuffix(path.Base(f), ".md"), FilePath: f}
+	}
+
+	meta := &SpaceMeta{ID: name, Name: name, Slug: name, TotalPages: len(mdFiles)}
+	for _, f := range mdFiles {
+		pm := pages[f]
+
+		var parent *PageMeta
+		for dir := path.Dir(f); dir != "." && parent == nil; dir = path.Dir(dir) {
+			parent = pages[dir+".md"]
+		}
+		if parent == nil {
+			pm.Position = fmt.Sprintf("a%04d", len(meta.Pages))
+			meta.Pages = append(meta.Pages, pm)
+			continue
+		}
+		parentID := parent.ID
+		pm.ParentPageID = &parentID
+		pm.Position = fmt.Sprintf("a%04d", len(parent.Children))
+		parent.Children = append(parent.Children, pm)
+		parent.HasChildren = true
+	}
+	return meta
+}
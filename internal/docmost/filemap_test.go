@@ -0,0 +1,101 @@
+package docmost
+
+import "testing"
+
+// TestAssignFilePaths_SameTitleCousins tests that pages with the same title under different parents get
+// the file in their parent's folder
+func TestAssignFilePaths_SameTitleCousins(t *testing.T) {
+	a := &PageMeta{ID: "a", Title: "2024", Children: []*PageMeta{{ID: "a1", Title: "Overview"}}}
+	b := &PageMeta{ID: "b", Title: "2025년", Children: []*PageMeta{{ID: "b1", Title: "Overview"}}}
+	meta := &SpaceMeta{Pages: []*PageMeta{a, b}}
+
+	// The later cousin's file comes first, so title-only matching would give both pages 2025년/Overview.md
+	files := []string{"2025년/Overview.md", "2024/Overview.md", "2024.md", "2025년.md", "2024/files/x/img.png"}
+	issues := AssignFilePaths(meta, files)
+
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %+v", issues)
+	}
+	if got := a.Children[0].FilePath; got != "2024/Overview.md" {
+		t.Errorf("a1 FilePath = %q, want 2024/Overview.md", got)
+	}
+	if got := b.Children[0].FilePath; got != "2025년/Overview.md" {
+		t.Errorf("b1 FilePath = %q, want 2025년/Overview.md", got)
+	}
+}
+
+// TestAssignFilePaths_DuplicateSiblings tests that of two sibling pages with the same title only the first
+// gets the single exported file and the other is reported as a conflict
+func TestAssignFilePaths_DuplicateSiblings(t *testing.T) {
+	first := &PageMeta{ID: "p1", Title: "Overview", Position: "a0"}
+	second := &PageMeta{ID: "p2", Title: "Overview", Position: "a1"}
+	meta := &SpaceMeta{Pages: []*PageMeta{first, second}}
+
+	issues := AssignFilePaths(meta, []string{"Overview.md"})
+
+	if first.FilePath != "Overview.md" || second.FilePath != "" {
+		t.Errorf("expected only the first sibling to get the file, got %q and %q", first.FilePath, second.FilePath)
+	}
+	if len(issues) != 1 || issues[0].PageID != "p2" || issues[0].Kind != FileConflict {
+		t.Fatalf("expected a conflict for p2, got %+v", issues)
+	}
+	if len(meta.FileIssues) != 1 {
+		t.Errorf("issues not recorded in metadata: %+v", meta.FileIssues)
+	}
+}
+
+// TestAssignFilePaths_Ambiguous tests that a page matching several files gets the first one and is reported
+// as ambiguous
+func TestAssignFilePaths_Ambiguous(t *testing.T) {
+	// The parent has no file, so its child's folder is unknown
+	child := &PageMeta{ID: "c", Title: "Notes"}
+	parent := &PageMeta{ID: "p", Title: "Missing", Children: []*PageMeta{child}}
+	meta := &SpaceMeta{Pages: []*PageMeta{parent}}
+
+	issues := AssignFilePaths(meta, []string{"x/Notes.md", "y/Notes.md"})
+
+	if child.FilePath != "x/Notes.md" {
+		t.Errorf("expected the first candidate, got %q", child.FilePath)
+	}
+	if len(issues) != 1 || issues[0].Kind != FileAmbiguous || len(issues[0].Candidates) != 2 {
+		t.Errorf("expected an ambiguous match with 2 candidates, got %+v", issues)
+	}
+}
+
+// TestInferSpaceMeta tests building the page tree of an export without _metadata.json from its files
+func TestInferSpaceMeta(t *testing.T) {
+	files := []string{
+		"Guide.md",
+		"Guide/Install.md",
+		"Guide/Install/Linux.md",
+		"Guide/files/abc/diagram.png",
+		"Orphans/Note.md",
+		"FAQ.md",
+	}
+	meta := InferSpaceMeta("docs", files)
+
+	if meta.Name != "docs" || meta.TotalPages != 5 {
+		t.Fatalf("unexpected space %q with %d pages", meta.Name, meta.TotalPages)
+	}
+	var titles []string
+	for _, pm := range meta.Pages {
+		titles = append(titles, pm.Title)
+	}
+	if len(titles) != 3 || titles[0] != "FAQ" || titles[1] != "Guide" || titles[2] != "Note" {
+		t.Fatalf("unexpected top-level pages %v", titles)
+	}
+
+	guide := meta.Pages[1]
+	if len(guide.Children) != 1 || guide.Children[0].FilePath != "Guide/Install.md" || !guide.HasChildren {
+		t.Fatalf("expected Guide/Install.md as the only child of Guide, got %+v", guide.Children)
+	}
+	linux := guide.Children[0].Children[0]
+	if linux.Title != "Linux" || linux.ParentPageID == nil || *linux.ParentPageID != "Guide/Install.md" {
+		t.Errorf("unexpected grandchild %+v", linux)
+	}
+
+	// Inferred metadata assigns every page back to its own file
+	if issues := AssignFilePaths(meta, files); len(issues) != 0 || linux.FilePath != "Guide/Install/Linux.md" {
+		t.Errorf("expected inferred pages to match their files, got issues %+v", issues)
+	}
+}
@@ -0,0 +1,112 @@
+package docmost
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SpaceFilter selects which spaces are exported.
+//
+// A rule is "slug:<glob>", "name:<glob>" or "id:<id>"; a rule without a prefix matches
+// either the slug or the name. Slug and name globs use path.Match syntax and ignore case.
+// A space is exported if it matches any include rule (or there are none) and no exclude rule.
+type SpaceFilter struct {
+	include []spaceRule
+	exclude []spaceRule
+}
+
+// spaceRule is a single parsed filter rule
+type spaceRule struct {
+	field   string // "slug", "name", "id" or "" for slug or name
+	pattern string
+}
+
+// NewSpaceFilter parses include and exclude rules into a filter
+func NewSpaceFilter(include, exclude []string) (*SpaceFilter, error) {
+	f := &SpaceFilter{}
+	var err error
+	if f.include, err = parseSpaceRules(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = parseSpaceRules(exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parseSpaceRules parses and validates filter rules
+func parseSpaceRules(rules []string) ([]spaceRule, error) {
+	parsed := make([]spaceRule, 0, len(rules))
+	for _, rule := range rules {
+		r := spaceRule{pattern: rule}
+		if field, pattern, ok := strings.Cut(rule, ":"); ok {
+			switch field {
+			case "slug", "name", "id":
+				r.field, r.pattern = field, pattern
+			}
+		}
+		if r.pattern == "" {
+			return nil, fmt.Errorf("invalid space rule %q: empty pattern", rule)
+		}
+		if r.field != "id" {
+			r.pattern = strings.ToLower(r.pattern)
+			if _, err := path.Match(r.pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid space rule %q: %w", rule, err)
+			}
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// matches reports whether the rule selects space
+func (r spaceRule) matches(space Space) bool {
+	glob := func(value string) bool {
+		ok, _ := path.Match(r.pattern, strings.ToLower(value))
+		return ok
+	}
+	switch r.field {
+	case "id":
+		return space.ID == r.pattern
+	case "slug":
+		return glob(space.Slug)
+	case "name":
+		return glob(space.Name)
+	default:
+		return glob(space.Slug) || glob(space.Name)
+	}
+}
+
+// Allows reports whether space should be exported
+func (f *SpaceFilter) Allows(space Space) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.include) > 0 && !anyRuleMatches(f.include, space) {
+		return false
+	}
+	return !anyRuleMatches(f.exclude, space)
+}
+
+// Apply splits spaces into the ones to export and the ones filtered out, keeping their order
+func (f *SpaceFilter) Apply(spaces []Space) (allowed, excluded []Space) {
+	for _, space := range spaces {
+		if f.Allows(space) {
+			allowed = append(allowed, space)
+		} else {
+			excluded = append(excluded, space)
+		}
+	}
+	return allowed, excluded
+}
+
+// anyRuleMatches reports whether any of rules selects space
+func anyRuleMatches(rules []spaceRule, space Space) bool {
+	for _, r := range rules {
+		if r.matches(space) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package docmost
+
+import "testing"
+
+// TestSpaceFilter tests matching spaces against include and exclude rules by name, slug and ID
+func TestSpaceFilter(t *testing.T) {
+	spaces := []Space{
+		{ID: "1", Name: "Engineering", Slug: "eng"},
+		{ID: "2", Name: "HR Internal", Slug: "hr"},
+		{ID: "3", Name: "Sandbox (jung)", Slug: "sandbox-jung"},
+		{ID: "4", Name: "Engineering Archive", Slug: "eng-archive"},
+	}
+
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"no rules", nil, nil, []string{"1", "2", "3", "4"}},
+		{"exclude by slug glob", nil, []string{"slug:sandbox-*"}, []string{"1", "2", "4"}},
+		{"exclude by name glob ignores case", nil, []string{"name:hr *"}, []string{"1", "3", "4"}},
+		{"exclude by id", nil, []string{"id:4"}, []string{"1", "2", "3"}},
+		{"include only", []string{"eng*"}, nil, []string{"1", "4"}},
+		{"exclude wins over include", []string{"eng*"}, []string{"slug:*-archive"}, []string{"1"}},
+		{"unprefixed rule matches name", nil, []string{"Sandbox*"}, []string{"1", "2", "4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewSpaceFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("NewSpaceFilter failed: %v", err)
+			}
+			allowed, excluded := filter.Apply(spaces)
+			if len(allowed)+len(excluded) != len(spaces) {
+				t.Fatalf("Apply lost spaces: %d allowed, %d excluded", len(allowed), len(excluded))
+			}
+			if len(allowed) != len(tt.want) {
+				t.Fatalf("allowed %v, want %v", allowed, tt.want)
+			}
+			for i, space := range allowed {
+				if space.ID != tt.want[i] {
+					t.Errorf("allowed[%d] = %s, want %s", i, space.ID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestNewSpaceFilter_InvalidRules tests that empty values and invalid patterns are rejected
+func TestNewSpaceFilter_InvalidRules(t *testing.T) {
+	for _, rule := range []string{"slug:", "name:[", "id:"} {
+		if _, err := NewSpaceFilter(nil, []string{rule}); err == nil {
+			t.Errorf("expected error for rule %q", rule)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package docmost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jung/doc2git/internal/fsutil"
+)
+
+// ErrStructureChanged is returned by PatchSpace when the page tree changed in a way
+// that single-page exports cannot reproduce, so the whole space has to be exported again
+var ErrStructureChanged = errors.New("page tree structure changed")
+
+// zipMagic is the signature at the start of a ZIP archive
+var zipMagic = []byte("PK\x03\x04")
+
+// ExportPage exports a single page (markdown format, without children) and streams it to w.
+// Depending on the server version the response is either a ZIP archive or the markdown itself.
+// It returns the number of bytes written.
+func (c *Client) ExportPage(ctx context.Context, pageID string, w io.Writer) (int64, error) {
+	reqBody := map[string]interface{}{
+		"pageId":             pageID,
+		"format":             "markdown",
+		"includeChildren":    false,
+		"includeAttachments": true,
+	}
+	body, _ := json.Marshal(reqBody)
+
+	resp, err := c.doRequest(ctx, "POST", "/api/pages/export", body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to export page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("export page failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("failed to download page export: %w", err)
+	}
+	return n, nil
+}
+
+// maxPatchedShare is the share of the pages of a space above which exporting the changed
+// pages one by one costs more than a single space export
+const maxPatchedShare = 0.5
+
+// ChangedPages compares the page trees of two metadata snapshots of the same space.
+// It returns the pages of current whose content changed since previous. If pages were
+// added, removed, renamed or moved, or a changed page has no known file in the previous
+// export, it returns ErrStructureChanged. So it does if a page of current has no updatedAt
+// timestamp, which leaves every page possibly changed, or if more than maxPatchedShare of
+// the pages changed.
+func ChangedPages(previous, current *SpaceMeta) ([]*PageMeta, error) {
+	type node struct {
+		page     *PageMeta
+		parentID string
+	}
+	flatten := func(meta *SpaceMeta) map[string]node {
+		nodes := make(map[string]node)
+		var walk func(pages []*PageMeta, parentID string)
+		walk = func(pages []*PageMeta, parentID string) {
+			for _, p := range pages {
+				nodes[p.ID] = node{page: p, parentID: parentID}
+				walk(p.Children, p.ID)
+			}
+		}
+		walk(meta.Pages, "")
+		return nodes
+	}
+
+	if previous.RootPageID != current.RootPageID {
+		return nil, fmt.Errorf("%w: root page changed", ErrStructureChanged)
+	}
+
+	before := flatten(previous)
+	after := flatten(current)
+	if len(before) != len(after) {
+		return nil, fmt.Errorf("%w: %d pages before, %d now", ErrStructureChanged, len(before), len(after))
+	}
+
+	var changed []*PageMeta
+	var walk func(pages []*PageMeta) error
+	walk = func(pages []*PageMeta) error {
+		for _, p := range pages {
+			old, ok := before[p.ID]
+			if !ok {
+				return fmt.Errorf("%w: page %s was added", ErrStructureChanged, p.Title)
+			}
+			if old.parentID != after[p.ID].parentID || old.page.Position != p.Position || old.page.Title != p.Title {
+				return fmt.Errorf("%w: page %s was moved or renamed", ErrStructureChanged, p.Title)
+			}
+			if p.UpdatedAt == "" {
+				return fmt.Errorf("%w: page %s has no updatedAt timestamp", ErrStructureChanged, p.Title)
+			}
+			if p.UpdatedAt != old.page.UpdatedAt {
+				if old.page.FilePath == "" {
+					return fmt.Errorf("%w: no exported file for page %s", ErrStructureChanged, p.Title)
+				}
+				changed = append(changed, p)
+			}
+			if err := walk(p.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(current.Pages); err != nil {
+		return nil, err
+	}
+	if float64(len(changed)) > maxPatchedShare*float64(len(after)) {
+		return nil, fmt.Errorf("%w: %d of %d pages changed", ErrStructureChanged, len(changed), len(after))
+	}
+	return changed, nil
+}
+
+// PatchSpace updates an earlier export of a space in dir to the current page tree by
+// exporting only the pages that changed since previous, the metadata of that export.
+// File paths and file issues are carried over from previous to current. It returns the written file
+// paths relative to dir, or ErrStructureChanged if the space needs a full export.
+// If Probe found no page export endpoint, it returns ErrPageExportUnsupported.
+func (c *Client) PatchSpace(ctx context.Context, previous, current *SpaceMeta, dir string) ([]string, error) {
+	if caps := c.Capabilities(); caps != nil && !caps.PageExport {
+		return nil, ErrPageExportUnsupported
+	}
+
+	changed, err := ChangedPages(previous, current)
+	if err != nil {
+		return nil, err
+	}
+
+	filePaths := make(map[string]string)
+	var collect func(pages []*PageMeta)
+	collect = func(pages []*PageMeta) {
+		for _, p := range pages {
+			filePaths[p.ID] = p.FilePath
+			collect(p.Children)
+		}
+	}
+	collect(previous.Pages)
+
+	var assign func(pages []*PageMeta)
+	assign = func(pages []*PageMeta) {
+		for _, p := range pages {
+			p.FilePath = filePaths[p.ID]
+			assign(p.Children)
+		}
+	}
+	assign(current.Pages)
+	current.FileIssues = previous.FileIssues
+
+	var files []string
+	for _, page := range changed {
+		written, err := c.DownloadPage(ctx, page, dir)
+		if err != nil {
+			return nil, fmt.Errorf("page %s: %w", page.Title, err)
+		}
+		files = append(files, written...)
+	}
+	return files, nil
+}
+
+// DownloadPage exports a single page and writes it over page.FilePath in dir.
+// Attachments in a ZIP response are extracted relative to the page file, so links
+// in the page resolve the same way as in the original export. The download is staged in
+// the system temp directory, so an interrupted export leaves nothing next to dir.
+// It returns the written file paths relative to dir.
+func (c *Client) DownloadPage(ctx context.Context, page *PageMeta, dir string) ([]string, error) {
+	if page.FilePath == "" {
+		return nil, fmt.Errorf("no file path for page %s", page.Title)
+	}
+
+	exportFile, err := os.CreateTemp("", "docmost-page-export-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file for export: %w", err)
+	}
+	defer os.Remove(exportFile.Name())
+
+	size, err := c.ExportPage(ctx, page.ID, exportFile)
+	if closeErr := exportFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write export: %w", closeErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	isZip, err := hasZipMagic(exportFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	if !isZip {
+		if c.extractLimits.MaxTotalSize > 0 && size > c.extractLimits.MaxTotalSize {
+			return nil, fmt.Errorf("%w: page export is %d bytes, limit is %d", ErrUnsafeArchive, size, c.extractLimits.MaxTotalSize)
+		}
+		if err := replaceFile(exportFile.Name(), filepath.Join(dir, filepath.FromSlash(page.FilePath))); err != nil {
+			return nil, err
+		}
+		return []string{page.FilePath}, nil
+	}
+
+	staging, err := os.MkdirTemp("", "docmost-page-export-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	entries, err := extractZip(exportFile.Name(), staging, c.extractLimits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract zip: %w", err)
+	}
+
+	markdown := ""
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, ".md") {
+			continue
+		}
+		if markdown != "" {
+			return nil, fmt.Errorf("page export contains more than one markdown file: %s, %s", markdown, entry)
+		}
+		markdown = entry
+	}
+	if markdown == "" {
+		return nil, fmt.Errorf("page export contains no markdown file")
+	}
+
+	pageDir := path.Dir(page.FilePath)
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		target := page.FilePath
+		if entry != markdown {
+			target = path.Join(pageDir, entry)
+		}
+		if err := replaceFile(filepath.Join(staging, filepath.FromSlash(entry)), filepath.Join(dir, filepath.FromSlash(target))); err != nil {
+			return nil, err
+		}
+		files = append(files, target)
+	}
+	return files, nil
+}
+
+// hasZipMagic reports whether the file at name starts with a ZIP signature
+func hasZipMagic(name string) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, len(zipMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to read export: %w", err)
+	}
+	return bytes.Equal(header[:n], zipMagic), nil
+}
+
+// replaceFile copies src over dst, creating parent directories and replacing any existing file.
+// src is in the system temp directory, which may be on another file system than dst.
+func replaceFile(src, dst string) error {
+	if err := fsutil.CopyFile(src, dst); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
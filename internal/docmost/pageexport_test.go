@@ -0,0 +1,168 @@
+package docmost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testSpaceMeta() *SpaceMeta {
+	return &SpaceMeta{
+		ID:   "space-1",
+		Name: "Space",
+		Pages: []*PageMeta{
+			{
+				ID: "a", Title: "A", Position: "a0", UpdatedAt: "1", FilePath: "A.md",
+				Children: []*PageMeta{
+					{ID: "b", Title: "B", Position: "a0", UpdatedAt: "1", FilePath: "A/B.md"},
+				},
+			},
+			{ID: "c", Title: "C", Position: "a1", UpdatedAt: "1", FilePath: "C.md"},
+		},
+	}
+}
+
+// TestChangedPages tests which page changes are patched and which require a full space export
+func TestChangedPages(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(meta *SpaceMeta)
+		wantIDs    []string
+		structural bool
+	}{
+		{"unchanged", func(meta *SpaceMeta) {}, nil, false},
+		{"content updated", func(meta *SpaceMeta) { meta.Pages[0].Children[0].UpdatedAt = "2" }, []string{"b"}, false},
+		{"missing timestamp", func(meta *SpaceMeta) { meta.Pages[1].UpdatedAt = "" }, nil, true},
+		{"most pages updated", func(meta *SpaceMeta) {
+			meta.Pages[0].UpdatedAt = "2"
+			meta.Pages[1].UpdatedAt = "2"
+		}, nil, true},
+		{"renamed", func(meta *SpaceMeta) { meta.Pages[1].Title = "C2" }, nil, true},
+		{"reordered", func(meta *SpaceMeta) { meta.Pages[1].Position = "Zz" }, nil, true},
+		{"moved", func(meta *SpaceMeta) {
+			meta.Pages = append(meta.Pages, meta.Pages[0].Children[0])
+			meta.Pages[0].Children = nil
+		}, nil, true},
+		{"added", func(meta *SpaceMeta) { meta.Pages = append(meta.Pages, &PageMeta{ID: "d", Title: "D"}) }, nil, true},
+		{"removed", func(meta *SpaceMeta) { meta.Pages = meta.Pages[:1] }, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := testSpaceMeta()
+			tt.modify(current)
+
+			changed, err := ChangedPages(testSpaceMeta(), current)
+			if tt.structural {
+				if !errors.Is(err, ErrStructureChanged) {
+					t.Fatalf("expected ErrStructureChanged, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(changed) != len(tt.wantIDs) {
+				t.Fatalf("got %d changed pages, want %v", len(changed), tt.wantIDs)
+			}
+			for i, page := range changed {
+				if page.ID != tt.wantIDs[i] {
+					t.Errorf("changed[%d] = %s, want %s", i, page.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+// newPageExportServer starts a server that answers page exports with the given body
+func newPageExportServer(t *testing.T, body []byte, exported *[]string) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/pages/export", func(w http.ResponseWriter, r *http.Request) {
+		*exported = append(*exported, r.URL.Path)
+		w.Write(body)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "user@example.com", "secret")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+// TestPatchSpace_MarkdownResponse tests patching a changed page from a plain Markdown page export
+func TestPatchSpace_MarkdownResponse(t *testing.T) {
+	var exported []string
+	client := newPageExportServer(t, []byte("# B v2\n"), &exported)
+
+	dir := filepath.Join(t.TempDir(), "space")
+	os.MkdirAll(filepath.Join(dir, "A"), 0755)
+	os.WriteFile(filepath.Join(dir, "A", "B.md"), []byte("# B\n"), 0644)
+
+	current := testSpaceMeta()
+	current.Pages[0].Children[0].UpdatedAt = "2"
+	for _, p := range []*PageMeta{current.Pages[0], current.Pages[0].Children[0], current.Pages[1]} {
+		p.FilePath = ""
+	}
+
+	files, err := client.PatchSpace(context.Background(), testSpaceMeta(), current, dir)
+	if err != nil {
+		t.Fatalf("PatchSpace failed: %v", err)
+	}
+	if len(exported) != 1 || len(files) != 1 || files[0] != "A/B.md" {
+		t.Fatalf("expected only A/B.md to be exported, got requests %v, files %v", exported, files)
+	}
+	if current.Pages[1].FilePath != "C.md" {
+		t.Errorf("file path not carried over: %q", current.Pages[1].FilePath)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "A", "B.md"))
+	if string(content) != "# B v2\n" {
+		t.Errorf("page not patched, content %q", content)
+	}
+}
+
+// TestDownloadPage_ZipResponse tests that a ZIP page export is extracted next to the page file,
+// together with its attachments
+func TestDownloadPage_ZipResponse(t *testing.T) {
+	zipPath := writeTestZip(t, t.TempDir(), map[string]string{
+		"B.md":          "# B\n![img](files/img.png)\n",
+		"files/img.png": "png",
+	})
+	body, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exported []string
+	client := newPageExportServer(t, body, &exported)
+
+	dir := filepath.Join(t.TempDir(), "space")
+	os.MkdirAll(dir, 0755)
+
+	files, err := client.DownloadPage(context.Background(), &PageMeta{ID: "b", Title: "B", FilePath: "A/B.md"}, dir)
+	if err != nil {
+		t.Fatalf("DownloadPage failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %v", files)
+	}
+
+	// Attachments are placed relative to the page so its links keep working
+	for _, name := range []string{"A/B.md", "A/files/img.png"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+}
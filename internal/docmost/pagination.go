@@ -0,0 +1,127 @@
+package docmost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// listPageSize is the number of items requested per page from list endpoints
+	listPageSize = 100
+
+	// maxListPages guards against a server that keeps reporting hasNextPage forever
+	maxListPages = 10000
+)
+
+// ErrInconsistentPagination is returned when the pagination metadata of a list response
+// contradicts the request or the items returned. Returning a partial list instead would
+// make RemoveOrphanedFiles delete the markdown of every page that went missing.
+var ErrInconsistentPagination = errors.New("inconsistent pagination metadata")
+
+// listData is the generic shape of a paginated list response
+type listData[T any] struct {
+	Items []T      `json:"items"`
+	Meta  ListMeta `json:"meta"`
+}
+
+// listAll requests every page of a paginated list endpoint and returns the concatenated items.
+// params are sent with every request in addition to page and limit.
+// idOf is used to detect items returned twice, which happens when the list shifts between requests.
+func listAll[T any](ctx context.Context, c *Client, op, endpoint string, params map[string]interface{}, idOf func(T) string) ([]T, error) {
+	var all []T
+	seen := make(map[string]bool)
+
+	for page := 1; ; page++ {
+		if page > maxListPages {
+			return nil, fmt.Errorf("%s: %w: more than %d pages reported", op, ErrInconsistentPagination, maxListPages)
+		}
+
+		data, err := fetchListPage[T](ctx, c, op, endpoint, params, page)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := checkListMeta(data.Meta, page, len(data.Items)); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, item := range data.Items {
+			id := idOf(item)
+			if seen[id] {
+				return nil, fmt.Errorf("%s: %w: item %s returned twice (page %d)", op, ErrInconsistentPagination, id, page)
+			}
+			seen[id] = true
+			all = append(all, item)
+		}
+
+		if !data.Meta.HasNextPage {
+			return all, nil
+		}
+	}
+}
+
+// fetchListPage requests a single page of a list endpoint
+func fetchListPage[T any](ctx context.Context, c *Client, op, endpoint string, params map[string]interface{}, page int) (*listData[T], error) {
+	reqBody := map[string]interface{}{
+		"page":  page,
+		"limit": listPageSize,
+	}
+	for k, v := range params {
+		reqBody[k] = v
+	}
+	body, _ := json.Marshal(reqBody)
+
+	resp, err := c.doRequest(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s failed with status %d: %s", op, resp.StatusCode, string(respBody))
+	}
+
+	var raw json.RawMessage
+	if err := c.decodeData(resp.Body, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Some releases return the items of an unpaginated list as a plain array
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var items []T
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("%s: failed to decode data: %w", op, err)
+		}
+		return &listData[T]{Items: items}, nil
+	}
+
+	var data listData[T]
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("%s: failed to decode data: %w", op, err)
+	}
+
+	return &data, nil
+}
+
+// checkListMeta validates the pagination metadata of the response for the requested page
+func checkListMeta(meta ListMeta, page, itemCount int) error {
+	if meta.Page != 0 && meta.Page != page {
+		return fmt.Errorf("%w: requested page %d but server returned page %d", ErrInconsistentPagination, page, meta.Page)
+	}
+	if meta.Limit > 0 && itemCount > meta.Limit {
+		return fmt.Errorf("%w: page %d has %d items but limit is %d", ErrInconsistentPagination, page, itemCount, meta.Limit)
+	}
+	if meta.HasNextPage && itemCount == 0 {
+		return fmt.Errorf("%w: page %d is empty but hasNextPage is set", ErrInconsistentPagination, page)
+	}
+	if page > 1 && !meta.HasPrevPage && meta.Page != 0 {
+		return fmt.Errorf("%w: page %d reports no previous page", ErrInconsistentPagination, page)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package docmost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newListServer starts a server that logs in any user and serves /api/spaces/ through handle
+func newListServer(t *testing.T, handle func(page, limit int) (items []Space, meta ListMeta)) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/spaces/", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Page  int `json:"page"`
+			Limit int `json:"limit"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		items, meta := handle(req.Page, req.Limit)
+		data, _ := json.Marshal(SpaceListData{Items: items, Meta: meta})
+		json.NewEncoder(w).Encode(APIResponse{Data: data, Success: true, Status: 200})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL, "user@example.com", "secret")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+// makeSpaces returns n spaces with IDs starting at offset
+func makeSpaces(offset, n int) []Space {
+	spaces := make([]Space, n)
+	for i := range spaces {
+		spaces[i] = Space{ID: fmt.Sprintf("space-%d", offset+i)}
+	}
+	return spaces
+}
+
+// TestListSpaces_FollowsAllPages tests that every page of results is fetched
+func TestListSpaces_FollowsAllPages(t *testing.T) {
+	const total = 250
+	var requested []int
+
+	client := newListServer(t, func(page, limit int) ([]Space, ListMeta) {
+		requested = append(requested, page)
+		offset := (page - 1) * limit
+		n := limit
+		if offset+n > total {
+			n = total - offset
+		}
+		return makeSpaces(offset, n), ListMeta{
+			Limit:       limit,
+			Page:        page,
+			HasNextPage: offset+n < total,
+			HasPrevPage: page > 1,
+		}
+	})
+
+	spaces, err := client.ListSpaces(context.Background())
+	if err != nil {
+		t.Fatalf("ListSpaces failed: %v", err)
+	}
+
+	if len(spaces) != total {
+		t.Errorf("expected %d spaces, got %d", total, len(spaces))
+	}
+	if len(requested) != 3 || requested[0] != 1 || requested[2] != 3 {
+		t.Errorf("expected pages [1 2 3] to be requested, got %v", requested)
+	}
+	if spaces[total-1].ID != fmt.Sprintf("space-%d", total-1) {
+		t.Errorf("unexpected last space %q", spaces[total-1].ID)
+	}
+}
+
+// TestListSpaces_InconsistentPagination tests that contradictory metadata is reported as an error
+func TestListSpaces_InconsistentPagination(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(page, limit int) ([]Space, ListMeta)
+	}{
+		{
+			name: "wrong page number",
+			handle: func(page, limit int) ([]Space, ListMeta) {
+				return makeSpaces(0, 1), ListMeta{Limit: limit, Page: page + 1, HasNextPage: true}
+			},
+		},
+		{
+			name: "empty page with next page",
+			handle: func(page, limit int) ([]Space, ListMeta) {
+				return nil, ListMeta{Limit: limit, Page: page, HasNextPage: true, HasPrevPage: page > 1}
+			},
+		},
+		{
+			name: "more items than limit",
+			handle: func(page, limit int) ([]Space, ListMeta) {
+				return makeSpaces(0, 3), ListMeta{Limit: 2, Page: page}
+			},
+		},
+		{
+			name: "same items on every page",
+			handle: func(page, limit int) ([]Space, ListMeta) {
+				return makeSpaces(0, 2), ListMeta{Limit: limit, Page: page, HasNextPage: page < 3, HasPrevPage: page > 1}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newListServer(t, tc.handle)
+
+			_, err := client.ListSpaces(context.Background())
+			if !errors.Is(err, ErrInconsistentPagination) {
+				t.Errorf("expected ErrInconsistentPagination, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package docmost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// MinSupportedVersion is the oldest Docmost release providing the space export API
+// the exporter relies on
+const MinSupportedVersion = "0.6.0"
+
+// ErrPageExportUnsupported is returned by PatchSpace when the server has no page export endpoint
+var ErrPageExportUnsupported = errors.New("server does not support page export")
+
+// Capabilities describes what the connected Docmost server supports
+type Capabilities struct {
+	Version    string // reported server version; empty if the server does not report it
+	Enveloped  bool   // responses are wrapped as {"data": ..., "success": ..., "status": ...}
+	PageExport bool   // /api/pages/export is available, enabling page-level incremental sync
+}
+
+// UnsupportedVersionError is returned by Probe when the server is older than MinSupportedVersion
+type UnsupportedVersionError struct {
+	Version string
+	Minimum string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("Docmost %s is not supported: upgrade Docmost to %s or later, or set DOCMOST_SKIP_VERSION_CHECK=true to try anyway",
+		e.Version, e.Minimum)
+}
+
+// WithVersionCheck sets whether Probe rejects servers older than MinSupportedVersion
+func WithVersionCheck(enabled bool) Option {
+	return func(c *Client) {
+		c.skipVersionCheck = !enabled
+	}
+}
+
+// Capabilities returns the result of the last successful Probe, or nil
+func (c *Client) Capabilities() *Capabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.caps
+}
+
+// Probe detects the version and API shape of the server and adapts the client to it.
+// It returns an *UnsupportedVersionError, along with the detected capabilities, if the
+// server is too old; the client then keeps no capabilities, so the next Probe checks again.
+// A server that does not report its version is assumed to be supported.
+func (c *Client) Probe(ctx context.Context) (*Capabilities, error) {
+	caps := &Capabilities{}
+
+	// The spaces listing is required, so it also decides whether the server is usable at all
+	raw, status, err := c.probeRequest(ctx, "/api/spaces/", map[string]interface{}{"page": 1, "limit": 1})
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("list spaces failed with status %d: %s", status, string(raw))
+	}
+	_, caps.Enveloped = unwrapEnvelope(raw)
+
+	// The version endpoint may be missing or reserved for admins; the version is then unknown
+	raw, status, err = c.probeRequest(ctx, "/api/version", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusOK {
+		var version struct {
+			CurrentVersion string `json:"currentVersion"`
+		}
+		data, _ := unwrapEnvelope(raw)
+		if json.Unmarshal(data, &version) == nil {
+			caps.Version = version.CurrentVersion
+		}
+	}
+
+	// Any answer other than a missing route (typically a validation error for the empty
+	// page ID) means the endpoint exists
+	raw, status, err = c.probeRequest(ctx, "/api/pages/export", map[string]interface{}{"pageId": "", "format": "markdown"})
+	if err != nil {
+		return nil, err
+	}
+	caps.PageExport = !routeMissing(status, raw)
+
+	if caps.Version != "" && !c.skipVersionCheck && compareVersions(caps.Version, MinSupportedVersion) < 0 {
+		return caps, &UnsupportedVersionError{Version: caps.Version, Minimum: MinSupportedVersion}
+	}
+
+	c.mu.Lock()
+	c.caps = caps
+	c.mu.Unlock()
+	return caps, nil
+}
+
+// probeRequest sends an authenticated request and returns its status and body
+func (c *Client) probeRequest(ctx context.Context, endpoint string, params map[string]interface{}) ([]byte, int, error) {
+	body, _ := json.Marshal(params)
+	resp, err := c.doRequest(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("probe %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, fmt.Errorf("probe %s: %w", endpoint, err)
+	}
+	return raw, resp.StatusCode, nil
+}
+
+// routeMissing reports whether a response means the endpoint does not exist: NestJS answers
+// unknown routes with 404 "Cannot POST <path>", and proxies in front of Docmost with a
+// non-JSON 404 or 405. A JSON 404 such as "Page not found" comes from an existing endpoint.
+func routeMissing(status int, body []byte) bool {
+	if status != http.StatusNotFound && status != http.StatusMethodNotAllowed {
+		return false
+	}
+	return bytes.Contains(body, []byte("Cannot POST")) || !json.Valid(body)
+}
+
+// decodeData decodes the data of a response body into v. Docmost wraps responses in
+// {"data": ..., "success": ..., "status": ...}; bodies without that envelope are decoded as they are.
+func (c *Client) decodeData(r io.Reader, v interface{}) error {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	data := []byte(raw)
+	if caps := c.Capabilities(); caps == nil || caps.Enveloped {
+		data, _ = unwrapEnvelope(raw)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unwrapEnvelope returns the data of an enveloped response and true, or raw and false
+// if raw is not enveloped
+func unwrapEnvelope(raw []byte) ([]byte, bool) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return raw, false
+	}
+	data, hasData := fields["data"]
+	_, hasSuccess := fields["success"]
+	_, hasStatus := fields["status"]
+	if !hasData || !(hasSuccess || hasStatus) {
+		return raw, false
+	}
+	return data, true
+}
+
+// compareVersions compares two versions such as "0.21.0" or "v0.7.1-beta" by their
+// numeric components, returning -1, 0 or 1. Unparseable components count as 0.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < 3; i++ {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) [3]int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+
+	var parts [3]int
+	for i, s := range strings.SplitN(v, ".", 3) {
+		parts[i], _ = strconv.Atoi(s)
+	}
+	return parts
+}
@@ -0,0 +1,64 @@
+package docmost
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestProbe_UnenvelopedResponses tests that a server answering without the response
+// envelope is detected and its responses, including plain array lists, are decoded
+func TestProbe_UnenvelopedResponses(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/spaces/", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(SpaceListData{Items: []Space{{ID: "s1", Name: "Docs"}}})
+	})
+	mux.HandleFunc("/api/pages/sidebar-pages", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Page{{ID: "p1", Title: "Home"}, {ID: "p2", Title: "About"}})
+	})
+	mux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"currentVersion": "v0.8.1"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, "user", "pass")
+	caps, err := client.Probe(context.Background())
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if caps.Enveloped || caps.Version != "v0.8.1" || caps.PageExport {
+		t.Errorf("unexpected capabilities: %+v", *caps)
+	}
+
+	spaces, err := client.ListSpaces(context.Background())
+	if err != nil || len(spaces) != 1 || spaces[0].Name != "Docs" {
+		t.Fatalf("ListSpaces = %+v, %v", spaces, err)
+	}
+	pages, err := client.ListSidebarPages(context.Background(), "s1")
+	if err != nil || len(pages) != 2 {
+		t.Fatalf("ListSidebarPages = %+v, %v", pages, err)
+	}
+}
+
+// TestCompareVersions tests comparing Docmost version strings, with or without "v" and pre-release suffixes
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"0.21.0", "0.6.0", 1},
+		{"v0.6.0", "0.6.0", 0},
+		{"0.5.9", "0.6.0", -1},
+		{"0.6.0-beta.1", "0.6.0", 0},
+		{"1.0", "0.99.99", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
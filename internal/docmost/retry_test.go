@@ -0,0 +1,110 @@
+package docmost
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetry retries quickly so tests do not sleep for real backoff delays
+var fastRetry = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+// TestDoRequest_RetriesTransientStatus tests that 502/429 responses are retried until success
+func TestDoRequest_RetriesTransientStatus(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/spaces/", func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			data, _ := json.Marshal(SpaceListData{Items: []Space{{ID: "s1"}}})
+			json.NewEncoder(w).Encode(APIResponse{Data: data})
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, "user", "pass", WithRetryPolicy(fastRetry))
+	spaces, err := client.ListSpaces(context.Background())
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if len(spaces) != 1 || calls != 3 {
+		t.Errorf("expected 1 space after 3 calls, got %d spaces after %d calls", len(spaces), calls)
+	}
+}
+
+// TestDoRequest_GivesUpAfterMaxRetries tests that the last failing response is returned
+func TestDoRequest_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/spaces/export", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, "user", "pass", WithRetryPolicy(fastRetry))
+	if _, err := client.ExportSpaceAsZip(context.Background(), "s1", io.Discard); err == nil {
+		t.Fatal("expected export to fail")
+	}
+	if calls != int32(fastRetry.MaxRetries+1) {
+		t.Errorf("expected %d attempts, got %d", fastRetry.MaxRetries+1, calls)
+	}
+}
+
+// TestDoRequest_DoesNotRetryNonIdempotent tests that unknown endpoints are attempted only once
+func TestDoRequest_DoesNotRetryNonIdempotent(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/pages/update", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, "user", "pass", WithRetryPolicy(fastRetry))
+	resp, err := client.doRequest(context.Background(), http.MethodPost, "/api/pages/update", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+// TestRetryPolicy_Backoff tests the exponential delay bounds and Retry-After handling
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for retry, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second} {
+		d := policy.backoff(retry, nil)
+		if d < max/2 || d > max {
+			t.Errorf("backoff(%d) = %v, expected between %v and %v", retry, d, max/2, max)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	if d := policy.backoff(1, resp); d != time.Second {
+		t.Errorf("expected Retry-After to be capped at MaxDelay, got %v", d)
+	}
+	resp.Header.Set("Retry-After", "0")
+	if d := policy.backoff(1, resp); d != 0 {
+		t.Errorf("expected Retry-After: 0 to yield no delay, got %v", d)
+	}
+}
@@ -0,0 +1,193 @@
+package docmost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// attachmentLinkPattern matches links to attachments stored next to a page in an export
+var attachmentLinkPattern = regexp.MustCompile(`\]\((files/[^)\s]+)\)`)
+
+// GetPage returns a single page by its ID or slug ID
+func (c *Client) GetPage(ctx context.Context, pageID string) (*Page, error) {
+	body, _ := json.Marshal(map[string]interface{}{"pageId": pageID})
+
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/pages/info", body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get page %s failed with status %d: %s", pageID, resp.StatusCode, string(respBody))
+	}
+
+	var page Page
+	if err := c.decodeData(resp.Body, &page); err != nil {
+		return nil, fmt.Errorf("get page %s: %w", pageID, err)
+	}
+	return &page, nil
+}
+
+// GetSubtreeMetadata retrieves metadata for the subtree of space rooted at root.
+// The tree is re-rooted: root becomes the first top-level page (without children) and its
+// children follow it at the top level, so the subtree can be published as a site of its own.
+// RootPageID is set to root's ID. FilePath is left empty; see AssignFilePaths and RerootExport.
+func (c *Client) GetSubtreeMetadata(ctx context.Context, space Space, root Page) (*SpaceMeta, error) {
+	// Page info does not always report hasChildren; listing the children of a leaf is harmless
+	root.HasChildren = true
+
+	meta, err := c.crawlSpace(ctx, space, []Page{root})
+	if err != nil {
+		return nil, err
+	}
+
+	rootMeta := meta.Pages[0]
+	children := rootMeta.Children
+	rootMeta.Children = nil
+	rootMeta.HasChildren = false
+	rootMeta.ParentPageID = nil
+	for _, child := range children {
+		child.ParentPageID = nil
+	}
+
+	meta.Pages = append([]*PageMeta{rootMeta}, children...)
+	meta.RootPageID = root.ID
+	return meta, nil
+}
+
+// RerootExport turns a full space export in dir into an export of the subtree described by meta
+// (see GetSubtreeMetadata). FilePath must hold the paths of the full export. The root page's
+// file and folder contents are moved to the top of dir, together with the attachments the root
+// page links to; all other files are deleted. FilePath is rebased onto the new layout, and
+// pages whose file lies outside the subtree are left without one.
+// It returns the remaining file paths relative to dir.
+func RerootExport(meta *SpaceMeta, dir string) ([]string, error) {
+	var root *PageMeta
+	for _, p := range meta.Pages {
+		if p.ID == meta.RootPageID {
+			root = p
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("root page %s not found in metadata", meta.RootPageID)
+	}
+	if root.FilePath == "" {
+		return nil, fmt.Errorf("no exported file for root page %s", root.Title)
+	}
+
+	rootFile := root.FilePath
+	rootDir := strings.TrimSuffix(rootFile, ".md")
+
+	staging := dir + ".subtree"
+	os.RemoveAll(staging)
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	// The root page's folder holds its descendants and their attachments; it becomes the top level
+	entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(rootDir)))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read subtree: %w", err)
+	}
+	for _, entry := range entries {
+		src := filepath.Join(dir, filepath.FromSlash(rootDir), entry.Name())
+		if err := os.Rename(src, filepath.Join(staging, entry.Name())); err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", entry.Name(), err)
+		}
+	}
+
+	// The root page itself moves up next to its former children
+	target := path.Base(rootFile)
+	if _, err := os.Stat(filepath.Join(staging, target)); err == nil {
+		return nil, fmt.Errorf("root page file %s collides with a file of its subtree", target)
+	}
+	rootSrc := filepath.Join(dir, filepath.FromSlash(rootFile))
+	content, err := os.ReadFile(rootSrc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read root page: %w", err)
+	}
+	if err := os.Rename(rootSrc, filepath.Join(staging, target)); err != nil {
+		return nil, fmt.Errorf("failed to move root page: %w", err)
+	}
+	moveLinkedAttachments(string(content), filepath.Dir(rootSrc), staging)
+
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to remove files outside the subtree: %w", err)
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return nil, fmt.Errorf("failed to move subtree into place: %w", err)
+	}
+
+	// Rebase file paths onto the new top level
+	root.FilePath = target
+	prefix := rootDir + "/"
+	var rebase func(pages []*PageMeta)
+	rebase = func(pages []*PageMeta) {
+		for _, p := range pages {
+			if p != root {
+				if strings.HasPrefix(p.FilePath, prefix) {
+					p.FilePath = strings.TrimPrefix(p.FilePath, prefix)
+				} else {
+					p.FilePath = ""
+				}
+			}
+			rebase(p.Children)
+		}
+	}
+	rebase(meta.Pages)
+
+	var files []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subtree files: %w", err)
+	}
+	return files, nil
+}
+
+// moveLinkedAttachments moves the attachments under srcDir that content links to
+// ("](files/...)") to the same relative path under dstDir. Missing files are ignored.
+func moveLinkedAttachments(content, srcDir, dstDir string) {
+	for _, match := range attachmentLinkPattern.FindAllStringSubmatch(content, -1) {
+		link := match[1]
+		if unescaped, err := url.PathUnescape(link); err == nil {
+			link = unescaped
+		}
+		link = path.Clean(link)
+		if !strings.HasPrefix(link, "files/") {
+			continue
+		}
+
+		src := filepath.Join(srcDir, filepath.FromSlash(link))
+		dst := filepath.Join(dstDir, filepath.FromSlash(link))
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			continue
+		}
+		os.Rename(src, dst)
+	}
+}
@@ -0,0 +1,82 @@
+package docmost
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestGetSubtreeMetadata tests that the root page and its children become the top level
+func TestGetSubtreeMetadata(t *testing.T) {
+	ts := &treeServer{children: newTree(2, 3)}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, "user", "pass")
+
+	root := ts.children["root"][1]
+	meta, err := client.GetSubtreeMetadata(context.Background(), Space{ID: "s1"}, root)
+	if err != nil {
+		t.Fatalf("GetSubtreeMetadata failed: %v", err)
+	}
+
+	// root.1, its 2 children and their 4 children
+	if meta.TotalPages != 7 || meta.RootPageID != "root.1" {
+		t.Errorf("expected 7 pages under root.1, got %d under %s", meta.TotalPages, meta.RootPageID)
+	}
+	got := strings.Join(flatten(meta.Pages), ",")
+	want := "root.1,root.1.1,root.1.1.1,root.1.1.0,root.1.0,root.1.0.1,root.1.0.0"
+	if got != want {
+		t.Errorf("page order = %s, want %s", got, want)
+	}
+	if len(meta.Pages[0].Children) != 0 || meta.Pages[1].ParentPageID != nil {
+		t.Errorf("root page should be a top-level page without children")
+	}
+}
+
+// TestRerootExport tests that only the subtree's files remain, moved to the top level
+func TestRerootExport(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Other.md":                            "other",
+		"Guides/API Guide.md":                 "![diagram](files/a1/diagram.png)",
+		"Guides/files/a1/diagram.png":         "png",
+		"Guides/files/b2/secret.png":          "sibling attachment",
+		"Guides/API Guide/Auth.md":            "![img](files/c3/token.png)",
+		"Guides/API Guide/files/c3/token.png": "png",
+		"Guides/API Guide/Auth/OAuth.md":      "oauth",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		os.WriteFile(p, []byte(content), 0644)
+	}
+
+	oauth := &PageMeta{ID: "oauth", Title: "OAuth", FilePath: "Guides/API Guide/Auth/OAuth.md"}
+	auth := &PageMeta{ID: "auth", Title: "Auth", FilePath: "Guides/API Guide/Auth.md", HasChildren: true, Children: []*PageMeta{oauth}}
+	stray := &PageMeta{ID: "stray", Title: "Other", FilePath: "Other.md"}
+	root := &PageMeta{ID: "guide", Title: "API Guide", FilePath: "Guides/API Guide.md"}
+	meta := &SpaceMeta{RootPageID: "guide", Pages: []*PageMeta{root, auth, stray}}
+
+	remaining, err := RerootExport(meta, dir)
+	if err != nil {
+		t.Fatalf("RerootExport failed: %v", err)
+	}
+
+	sort.Strings(remaining)
+	want := []string{"API Guide.md", "Auth.md", "Auth/OAuth.md", "files/a1/diagram.png", "files/c3/token.png"}
+	if strings.Join(remaining, ",") != strings.Join(want, ",") {
+		t.Errorf("remaining files = %v, want %v", remaining, want)
+	}
+
+	if root.FilePath != "API Guide.md" || auth.FilePath != "Auth.md" || oauth.FilePath != "Auth/OAuth.md" {
+		t.Errorf("file paths not rebased: %s, %s, %s", root.FilePath, auth.FilePath, oauth.FilePath)
+	}
+	if stray.FilePath != "" {
+		t.Errorf("page outside the subtree kept file %s", stray.FilePath)
+	}
+}
@@ -0,0 +1,83 @@
+package docmost
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// TransportConfig configures TLS and proxying of the connection to Docmost
+type TransportConfig struct {
+	CAFile   string // PEM file of CA certificates trusted in addition to the system roots
+	CertFile string // PEM client certificate for mutual TLS, used together with KeyFile
+	KeyFile  string // PEM private key of CertFile
+	ProxyURL string // HTTP(S) or SOCKS5 proxy; empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+}
+
+// WithTransport sets the TLS and proxy settings of the client
+func WithTransport(tc TransportConfig) Option {
+	return func(c *Client) {
+		c.transportConfig = &tc
+	}
+}
+
+// WithTimeout sets the time limit for a single request, including reading the response body.
+// 0 disables the limit, leaving requests bounded only by their context.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// newTransport builds an HTTP transport from tc, starting from the defaults of http.DefaultTransport
+func newTransport(tc TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if tc.CAFile != "" {
+		pem, err := os.ReadFile(tc.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", tc.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if tc.CertFile != "" || tc.KeyFile != "" {
+		if tc.CertFile == "" || tc.KeyFile == "" {
+			return nil, errors.New("a client certificate requires both a certificate and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(tc.CertFile, tc.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if tc.ProxyURL != "" {
+		proxy, err := url.Parse(tc.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("invalid proxy URL %s: scheme must be http, https or socks5", tc.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return transport, nil
+}
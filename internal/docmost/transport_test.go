@@ -0,0 +1,103 @@
+package docmost
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// writeServerCert writes the certificate and key of a TLS test server as PEM files
+func writeServerCert(t *testing.T, server *httptest.Server) (certFile, keyFile string) {
+	t.Helper()
+
+	cert := server.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600)
+	return certFile, keyFile
+}
+
+// TestTransport_CustomCAAndClientCert tests that a server signed by a private CA is trusted
+// only with the CA file, and that the client certificate is presented for mutual TLS
+func TestTransport_CustomCAAndClientCert(t *testing.T) {
+	var clientCerts int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			atomic.AddInt32(&clientCerts, 1)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+	certFile, keyFile := writeServerCert(t, server)
+
+	untrusted, _ := NewClient(server.URL, "user", "pass", WithTransport(TransportConfig{}))
+	if err := untrusted.Login(context.Background()); err == nil {
+		t.Fatal("expected the server certificate to be rejected without the CA file")
+	}
+
+	client, err := NewClient(server.URL, "user", "pass",
+		WithTransport(TransportConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.Login(context.Background()); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if atomic.LoadInt32(&clientCerts) != 1 {
+		t.Errorf("client certificate was not presented")
+	}
+}
+
+// TestTransport_Proxy tests that requests are sent through the configured proxy
+func TestTransport_Proxy(t *testing.T) {
+	var proxiedHost atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost.Store(r.URL.Host)
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient("http://docmost.internal", "user", "pass", WithTransport(TransportConfig{ProxyURL: proxy.URL}))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.Login(context.Background()); err != nil {
+		t.Fatalf("login through proxy failed: %v", err)
+	}
+	if host, _ := proxiedHost.Load().(string); host != "docmost.internal" {
+		t.Errorf("expected the proxy to receive a request for docmost.internal, got %q", host)
+	}
+}
+
+// TestTransport_InvalidConfig tests that invalid CA, client certificate and proxy settings are rejected
+func TestTransport_InvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0644)
+
+	tests := map[string]TransportConfig{
+		"CA file without certificates": {CAFile: notPEM},
+		"missing CA file":              {CAFile: filepath.Join(dir, "missing.pem")},
+		"certificate without key":      {CertFile: notPEM},
+		"unsupported proxy scheme":     {ProxyURL: "ftp://proxy.internal:21"},
+	}
+	for name, tc := range tests {
+		if _, err := NewClient("https://docmost.internal", "user", "pass", WithTransport(tc)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
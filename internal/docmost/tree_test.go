@@ -0,0 +1,151 @@
+package docmost
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// treeServer serves a page tree where every page listed in children has those child pages.
+// Listing the children of a page in failOn returns 500.
+type treeServer struct {
+	children map[string][]Page
+	failOn   string
+
+	mu            sync.Mutex
+	inFlight      int
+	maxInFlight   int
+	maxGoroutines int
+}
+
+func (ts *treeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/auth/login" {
+		return
+	}
+
+	var req struct {
+		SpaceID string `json:"spaceId"`
+		PageID  string `json:"pageId"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	ts.mu.Lock()
+	ts.inFlight++
+	if ts.inFlight > ts.maxInFlight {
+		ts.maxInFlight = ts.inFlight
+	}
+	if n := runtime.NumGoroutine(); n > ts.maxGoroutines {
+		ts.maxGoroutines = n
+	}
+	ts.mu.Unlock()
+	defer func() {
+		ts.mu.Lock()
+		ts.inFlight--
+		ts.mu.Unlock()
+	}()
+	time.Sleep(2 * time.Millisecond)
+
+	key := req.PageID
+	if key == "" {
+		key = "root"
+	}
+	if key == ts.failOn {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	data, _ := json.Marshal(PageListData{Items: ts.children[key]})
+	json.NewEncoder(w).Encode(APIResponse{Data: data})
+}
+
+// newTree builds a tree with the given fan-out and depth. Positions are assigned in
+// reverse order of the IDs so that sorting is observable.
+func newTree(fanOut, depth int) map[string][]Page {
+	children := make(map[string][]Page)
+	var build func(parent string, level int)
+	build = func(parent string, level int) {
+		for i := 0; i < fanOut; i++ {
+			id := fmt.Sprintf("%s.%d", parent, i)
+			page := Page{ID: id, Title: id, Position: fmt.Sprintf("p%02d", fanOut-i), HasChildren: level < depth}
+			children[parent] = append(children[parent], page)
+			if level < depth {
+				build(id, level+1)
+			}
+		}
+	}
+	build("root", 1)
+	return children
+}
+
+// flatten returns page IDs in depth-first order
+func flatten(pages []*PageMeta) []string {
+	var ids []string
+	for _, p := range pages {
+		ids = append(ids, p.ID)
+		ids = append(ids, flatten(p.Children)...)
+	}
+	return ids
+}
+
+// TestGetSpaceMetadata_ConcurrentCrawl tests that the crawl is bounded, complete and deterministic
+func TestGetSpaceMetadata_ConcurrentCrawl(t *testing.T) {
+	ts := &treeServer{children: newTree(3, 4)}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, "user", "pass", WithCrawlConcurrency(3))
+	idle := runtime.NumGoroutine()
+
+	var previous string
+	for run := 0; run < 3; run++ {
+		meta, err := client.GetSpaceMetadata(context.Background(), Space{ID: "s1"})
+		if err != nil {
+			t.Fatalf("GetSpaceMetadata failed: %v", err)
+		}
+
+		// 3 + 9 + 27 + 81 pages
+		if meta.TotalPages != 120 {
+			t.Errorf("expected 120 pages, got %d", meta.TotalPages)
+		}
+		if meta.Pages[0].ID != "root.2" {
+			t.Errorf("expected pages sorted by position, first is %s", meta.Pages[0].ID)
+		}
+
+		order := strings.Join(flatten(meta.Pages), ",")
+		if previous != "" && order != previous {
+			t.Errorf("page order differs between runs")
+		}
+		previous = order
+	}
+
+	if ts.maxInFlight > 3 {
+		t.Errorf("expected at most 3 requests in flight, got %d", ts.maxInFlight)
+	}
+	// Workers and connections, not one goroutine per page
+	if n := ts.maxGoroutines - idle; n > 30 {
+		t.Errorf("expected a bounded number of goroutines, got %d more than idle", n)
+	}
+}
+
+// TestGetSpaceMetadata_ChildListingFailure tests that a failed child listing fails the crawl
+func TestGetSpaceMetadata_ChildListingFailure(t *testing.T) {
+	ts := &treeServer{children: newTree(3, 3), failOn: "root.1.2"}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	client, _ := NewClient(server.URL, "user", "pass", WithRetryPolicy(RetryPolicy{}))
+	meta, err := client.GetSpaceMetadata(context.Background(), Space{ID: "s1"})
+	if err == nil {
+		t.Fatalf("expected an error, got a tree with %d pages", meta.TotalPages)
+	}
+	if !strings.Contains(err.Error(), "root.1.2") {
+		t.Errorf("expected error to name the failing page, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package fakedocmost
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault is an error injected into the responses of one endpoint
+type Fault struct {
+	Path       string        // request path the fault applies to, e.g. "/api/spaces/export"
+	Status     int           // respond with this status instead of handling the request
+	RetryAfter string        // Retry-After header sent with Status, if set
+	Delay      time.Duration // wait this long before responding
+	Truncate   bool          // cut the response body in half
+	Times      int           // number of requests affected; 0 means every request
+}
+
+// ParseFault parses a fault given as "<path>:<kind>[:<times>]", where kind is an HTTP
+// status code, "slow=<duration>" or "truncate". For example "/api/spaces/export:truncate:1"
+// truncates the first export, and "/api/pages/sidebar-pages:429:3" rejects three listings.
+func ParseFault(spec string) (Fault, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 || !strings.HasPrefix(parts[0], "/") {
+		return Fault{}, fmt.Errorf("invalid fault %q: expected <path>:<kind>[:<times>]", spec)
+	}
+
+	f := Fault{Path: parts[0]}
+	kind := parts[1]
+	switch {
+	case kind == "truncate":
+		f.Truncate = true
+	case strings.HasPrefix(kind, "slow="):
+		delay, err := time.ParseDuration(strings.TrimPrefix(kind, "slow="))
+		if err != nil {
+			return Fault{}, fmt.Errorf("invalid fault %q: %w", spec, err)
+		}
+		f.Delay = delay
+	default:
+		status, err := strconv.Atoi(kind)
+		if err != nil || status < 100 || status > 599 {
+			return Fault{}, fmt.Errorf("invalid fault %q: unknown kind %q", spec, kind)
+		}
+		f.Status = status
+	}
+
+	if len(parts) == 3 {
+		times, err := strconv.Atoi(parts[2])
+		if err != nil || times < 1 {
+			return Fault{}, fmt.Errorf("invalid fault %q: times must be a positive number", spec)
+		}
+		f.Times = times
+	}
+	return f, nil
+}
+
+// activeFault is an injected fault with its remaining uses
+type activeFault struct {
+	Fault
+	remaining int // -1 for unlimited
+}
@@ -0,0 +1,157 @@
+package fakedocmost
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Space is a space served by the fake server
+type Space struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	dir   string  // the space's pages directory
+	pages []*Page // top-level pages
+}
+
+// Page is a page served by the fake server
+type Page struct {
+	ID           string    `json:"id"`
+	SlugID       string    `json:"slugId"`
+	Title        string    `json:"title"`
+	Position     string    `json:"position"`
+	ParentPageID *string   `json:"parentPageId"`
+	SpaceID      string    `json:"spaceId"`
+	HasChildren  bool      `json:"hasChildren"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+
+	file     string // markdown file relative to the space's pages directory, "/" separated
+	children []*Page
+}
+
+// LoadFixtures reads spaces from dir.
+//
+// Every subdirectory of dir that contains a space.json is a space. space.json may set
+// "id", "name", "slug" and "description"; the slug defaults to the directory name and the
+// name to the slug. The space's pages/ directory is laid out like a Docmost markdown export:
+// each page is "<title>.md" and its children live in a folder named "<title>". Pages are
+// ordered by file name, and their IDs are derived from their path so they are stable across runs.
+// Other files (such as files/ attachment folders) are included in exports as-is.
+func LoadFixtures(dir string) ([]*Space, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var spaces []*Space
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		spaceDir := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(spaceDir, "space.json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read space %s: %w", entry.Name(), err)
+		}
+
+		space := &Space{}
+		if err := json.Unmarshal(data, space); err != nil {
+			return nil, fmt.Errorf("failed to parse %s/space.json: %w", entry.Name(), err)
+		}
+		if space.Slug == "" {
+			space.Slug = entry.Name()
+		}
+		if space.Name == "" {
+			space.Name = space.Slug
+		}
+		if space.ID == "" {
+			space.ID = fixtureID("space", space.Slug)
+		}
+		space.dir = filepath.Join(spaceDir, "pages")
+
+		info, err := os.Stat(filepath.Join(spaceDir, "space.json"))
+		if err != nil {
+			return nil, err
+		}
+		space.CreatedAt = info.ModTime().UTC()
+		space.UpdatedAt = space.CreatedAt
+
+		if space.pages, err = loadPages(space, "", nil); err != nil {
+			return nil, fmt.Errorf("failed to load pages of space %s: %w", space.Slug, err)
+		}
+		spaces = append(spaces, space)
+	}
+	return spaces, nil
+}
+
+// loadPages loads the pages in the folder rel of a space's pages directory
+func loadPages(space *Space, rel string, parentID *string) ([]*Page, error) {
+	entries, err := os.ReadDir(filepath.Join(space.dir, filepath.FromSlash(rel)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	pages := make([]*Page, 0, len(names))
+	for i, name := range names {
+		file := path.Join(rel, name)
+		info, err := os.Stat(filepath.Join(space.dir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+
+		id := fixtureID("page", space.Slug+"/"+file)
+		p := &Page{
+			ID:           id,
+			SlugID:       id[:10],
+			Title:        strings.TrimSuffix(name, ".md"),
+			Position:     fmt.Sprintf("a%04d", i),
+			ParentPageID: parentID,
+			SpaceID:      space.ID,
+			UpdatedAt:    info.ModTime().UTC(),
+			file:         file,
+		}
+		if p.children, err = loadPages(space, strings.TrimSuffix(file, ".md"), &p.ID); err != nil {
+			return nil, err
+		}
+		p.HasChildren = len(p.children) > 0
+		pages = append(pages, p)
+
+		if p.UpdatedAt.After(space.UpdatedAt) {
+			space.UpdatedAt = p.UpdatedAt
+		}
+	}
+	return pages, nil
+}
+
+// fixtureID derives a stable UUID-like ID from a fixture path
+func fixtureID(kind, key string) string {
+	sum := sha1.Sum([]byte(kind + ":" + key))
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
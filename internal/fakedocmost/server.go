@@ -0,0 +1,524 @@
+// Package fakedocmost implements an in-memory Docmost API server backed by a fixture directory.
+// It serves the endpoints used by the exporter and can inject faults, so the client and the
+// whole sync pipeline can be exercised without a live Docmost instance.
+package fakedocmost
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Default login credentials accepted by the server
+const (
+	DefaultEmail    = "admin@example.com"
+	DefaultPassword = "password"
+)
+
+// DefaultVersion is the Docmost version reported by /api/version
+const DefaultVersion = "0.21.0"
+
+// Server is a fake Docmost API server
+type Server struct {
+	spaces   []*Space
+	email    string
+	password string
+	apiToken string
+	version  string
+	disabled map[string]bool
+
+	sidebarTimestamps bool // whether sidebar-pages responses include updatedAt
+
+	mu       sync.Mutex
+	sessions map[string]bool
+	faults   []*activeFault
+	requests map[string]int
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithCredentials sets the email and password accepted by /api/auth/login
+func WithCredentials(email, password string) Option {
+	return func(s *Server) {
+		s.email = email
+		s.password = password
+	}
+}
+
+// WithAPIToken makes the server accept "Authorization: Bearer <token>" in place of a session
+func WithAPIToken(token string) Option {
+	return func(s *Server) {
+		s.apiToken = token
+	}
+}
+
+// WithVersion sets the version reported by /api/version; an empty version disables the endpoint
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// WithoutEndpoints makes the server answer requests for paths as unknown routes,
+// like a Docmost release that does not have them
+func WithoutEndpoints(paths ...string) Option {
+	return func(s *Server) {
+		for _, p := range paths {
+			s.disabled[p] = true
+		}
+	}
+}
+
+// WithoutSidebarTimestamps leaves updatedAt out of sidebar-pages responses, like Docmost
+// releases that only report it in page info
+func WithoutSidebarTimestamps() Option {
+	return func(s *Server) {
+		s.sidebarTimestamps = false
+	}
+}
+
+// WithFaults injects faults from the start (see Inject)
+func WithFaults(faults ...Fault) Option {
+	return func(s *Server) {
+		for _, f := range faults {
+			s.Inject(f)
+		}
+	}
+}
+
+// New creates a server serving spaces
+func New(spaces []*Space, opts ...Option) *Server {
+	s := &Server{
+		spaces:   spaces,
+		email:    DefaultEmail,
+		password: DefaultPassword,
+		version:  DefaultVersion,
+		disabled: make(map[string]bool),
+		sessions: make(map[string]bool),
+		requests: make(map[string]int),
+
+		sidebarTimestamps: true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewTestServer loads fixturesDir and starts the fake server on a local port for the
+// duration of the test
+func NewTestServer(t testing.TB, fixturesDir string, opts ...Option) (*httptest.Server, *Server) {
+	t.Helper()
+
+	spaces, err := LoadFixtures(fixturesDir)
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+	fake := New(spaces, opts...)
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return server, fake
+}
+
+// Inject adds a fault. Faults for the same path are applied in the order they were added,
+// each for its number of requests.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remaining := f.Times
+	if remaining == 0 {
+		remaining = -1
+	}
+	s.faults = append(s.faults, &activeFault{Fault: f, remaining: remaining})
+}
+
+// ExpireSessions invalidates all session cookies, as if they had timed out
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]bool)
+}
+
+// Requests returns the number of requests received for path
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// takeFault returns the next fault for path and uses it up, or nil
+func (s *Server) takeFault(path string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[path]++
+	for _, f := range s.faults {
+		if f.Path != path || f.remaining == 0 {
+			continue
+		}
+		if f.remaining > 0 {
+			f.remaining--
+		}
+		fault := f.Fault
+		return &fault
+	}
+	return nil
+}
+
+// ServeHTTP applies injected faults and handles the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fault := s.takeFault(r.URL.Path)
+	if fault == nil {
+		s.route(w, r)
+		return
+	}
+
+	if fault.Delay > 0 {
+		select {
+		case <-time.After(fault.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fault.Status != 0 {
+		if fault.RetryAfter != "" {
+			w.Header().Set("Retry-After", fault.RetryAfter)
+		}
+		writeError(w, fault.Status, "injected fault")
+		return
+	}
+
+	if fault.Truncate {
+		rec := httptest.NewRecorder()
+		s.route(rec, r)
+		for key, values := range rec.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(rec.Code)
+		body := rec.Body.Bytes()
+		w.Write(body[:len(body)/2])
+		return
+	}
+
+	s.route(w, r)
+}
+
+// route dispatches a request to its endpoint handler
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.disabled[r.URL.Path] || (r.URL.Path == "/api/version" && s.version == "") {
+		writeError(w, http.StatusNotFound, "Cannot POST "+r.URL.Path)
+		return
+	}
+	if r.URL.Path == "/api/auth/login" {
+		s.handleLogin(w, r)
+		return
+	}
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/spaces/":
+		s.handleSpaces(w, r)
+	case "/api/pages/sidebar-pages":
+		s.handleSidebarPages(w, r)
+	case "/api/pages/info":
+		s.handlePageInfo(w, r)
+	case "/api/spaces/export":
+		s.handleSpaceExport(w, r)
+	case "/api/pages/export":
+		s.handlePageExport(w, r)
+	case "/api/version":
+		writeData(w, map[string]string{"currentVersion": s.version, "latestVersion": s.version})
+	default:
+		writeError(w, http.StatusNotFound, "Cannot POST "+r.URL.Path)
+	}
+}
+
+// authorized reports whether r carries a valid session cookie or API token
+func (s *Server) authorized(r *http.Request) bool {
+	if s.apiToken != "" && r.Header.Get("Authorization") == "Bearer "+s.apiToken {
+		return true
+	}
+	cookie, err := r.Cookie("authToken")
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[cookie.Value]
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Email != s.email || req.Password != s.password {
+		writeError(w, http.StatusUnauthorized, "Email or password does not match")
+		return
+	}
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	s.sessions[token] = true
+	s.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: "authToken", Value: token, Path: "/", HttpOnly: true})
+	writeData(w, struct{}{})
+}
+
+// listRequest holds the parameters shared by list endpoints
+type listRequest struct {
+	SpaceID string `json:"spaceId"`
+	PageID  string `json:"pageId"`
+	Page    int    `json:"page"`
+	Limit   int    `json:"limit"`
+}
+
+func (s *Server) handleSpaces(w http.ResponseWriter, r *http.Request) {
+	var req listRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	writeList(w, s.spaces, req)
+}
+
+func (s *Server) handleSidebarPages(w http.ResponseWriter, r *http.Request) {
+	var req listRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	if req.PageID != "" {
+		_, page := s.findPage(req.PageID)
+		if page == nil {
+			writeError(w, http.StatusNotFound, "Page not found")
+			return
+		}
+		writeList(w, s.sidebarItems(page.children), req)
+		return
+	}
+
+	space := s.findSpace(req.SpaceID)
+	if space == nil {
+		writeError(w, http.StatusNotFound, "Space not found")
+		return
+	}
+	writeList(w, s.sidebarItems(space.pages), req)
+}
+
+// sidebarItems returns pages as listed by sidebar-pages
+func (s *Server) sidebarItems(pages []*Page) []interface{} {
+	items := make([]interface{}, len(pages))
+	for i, p := range pages {
+		if s.sidebarTimestamps {
+			items[i] = p
+			continue
+		}
+		items[i] = map[string]interface{}{
+			"id":           p.ID,
+			"slugId":       p.SlugID,
+			"title":        p.Title,
+			"position":     p.Position,
+			"parentPageId": p.ParentPageID,
+			"spaceId":      p.SpaceID,
+			"hasChildren":  p.HasChildren,
+		}
+	}
+	return items
+}
+
+func (s *Server) handlePageInfo(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PageID string `json:"pageId"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	_, page := s.findPage(req.PageID)
+	if page == nil {
+		writeError(w, http.StatusNotFound, "Page not found")
+		return
+	}
+	writeData(w, page)
+}
+
+func (s *Server) handleSpaceExport(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SpaceID string `json:"spaceId"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	space := s.findSpace(req.SpaceID)
+	if space == nil {
+		writeError(w, http.StatusNotFound, "Space not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	zw := zip.NewWriter(w)
+	filepath.WalkDir(space.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(space.dir, p)
+		if err != nil {
+			return err
+		}
+		return addZipFile(zw, filepath.ToSlash(rel), p)
+	})
+	zw.Close()
+}
+
+func (s *Server) handlePageExport(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PageID string `json:"pageId"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	space, page := s.findPage(req.PageID)
+	if page == nil {
+		writeError(w, http.StatusNotFound, "Page not found")
+		return
+	}
+
+	// The page alone, with the attachment folder stored next to it
+	w.Header().Set("Content-Type", "application/zip")
+	zw := zip.NewWriter(w)
+	addZipFile(zw, path.Base(page.file), filepath.Join(space.dir, filepath.FromSlash(page.file)))
+	filesDir := filepath.Join(space.dir, filepath.FromSlash(path.Dir(page.file)), "files")
+	filepath.WalkDir(filesDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filesDir, p)
+		if err != nil {
+			return err
+		}
+		return addZipFile(zw, "files/"+filepath.ToSlash(rel), p)
+	})
+	zw.Close()
+}
+
+// findSpace returns the space with the given ID, or nil
+func (s *Server) findSpace(id string) *Space {
+	for _, space := range s.spaces {
+		if space.ID == id {
+			return space
+		}
+	}
+	return nil
+}
+
+// findPage returns the page with the given ID or slug ID and its space, or nil
+func (s *Server) findPage(id string) (*Space, *Page) {
+	var find func(pages []*Page) *Page
+	find = func(pages []*Page) *Page {
+		for _, p := range pages {
+			if p.ID == id || p.SlugID == id {
+				return p
+			}
+			if found := find(p.children); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+
+	for _, space := range s.spaces {
+		if page := find(space.pages); page != nil {
+			return space, page
+		}
+	}
+	return nil, nil
+}
+
+// addZipFile adds the file at src to zw as name
+func addZipFile(zw *zip.Writer, name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
+
+// writeList writes one page of items in Docmost's paginated list format
+func writeList[T any](w http.ResponseWriter, items []T, req listRequest) {
+	page, limit := req.Page, req.Limit
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	start := (page - 1) * limit
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	writeData(w, map[string]interface{}{
+		"items": items[start:end],
+		"meta": map[string]interface{}{
+			"limit":       limit,
+			"page":        page,
+			"hasNextPage": end < len(items),
+			"hasPrevPage": page > 1,
+		},
+	})
+}
+
+// writeData writes a successful response in Docmost's envelope
+func writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    data,
+		"success": true,
+		"status":  http.StatusOK,
+	})
+}
+
+// writeError writes an error response in NestJS's format
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    message,
+		"error":      http.StatusText(status),
+		"statusCode": status,
+	})
+}
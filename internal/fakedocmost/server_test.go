@@ -0,0 +1,271 @@
+package fakedocmost
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jung/doc2git/internal/docmost"
+)
+
+// newClient starts a fake server on the test fixtures and returns a client for it
+func newClient(t *testing.T, opts ...Option) (*docmost.Client, *Server) {
+	t.Helper()
+
+	server, fake := NewTestServer(t, "testdata", opts...)
+	client, err := docmost.NewClient(server.URL, DefaultEmail, DefaultPassword,
+		docmost.WithRetryPolicy(docmost.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := client.Login(context.Background()); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	return client, fake
+}
+
+// findSpace returns the listed space with the given slug
+func findSpace(t *testing.T, client *docmost.Client, slug string) docmost.Space {
+	t.Helper()
+
+	spaces, err := client.ListSpaces(context.Background())
+	if err != nil {
+		t.Fatalf("ListSpaces failed: %v", err)
+	}
+	for _, space := range spaces {
+		if space.Slug == slug {
+			return space
+		}
+	}
+	t.Fatalf("space %s not found in %+v", slug, spaces)
+	return docmost.Space{}
+}
+
+// TestExportSpace tests exporting a fixture space through the real client
+func TestExportSpace(t *testing.T) {
+	client, _ := newClient(t)
+	space := findSpace(t, client, "engineering")
+
+	dir := filepath.Join(t.TempDir(), "engineering")
+	exported, err := client.ExportSpace(context.Background(), space, dir)
+	if err != nil {
+		t.Fatalf("ExportSpace failed: %v", err)
+	}
+
+	if exported.Metadata.TotalPages != 7 {
+		t.Errorf("expected 7 pages, got %d", exported.Metadata.TotalPages)
+	}
+	if len(exported.Metadata.FileIssues) != 0 {
+		t.Errorf("unexpected file issues: %+v", exported.Metadata.FileIssues)
+	}
+
+	reference := exported.Metadata.Pages[1]
+	if reference.Title != "Reference" || len(reference.Children) != 2 {
+		t.Fatalf("unexpected page tree: %+v", reference)
+	}
+	if got := reference.Children[1].Children[0].FilePath; got != "Reference/2025/Overview.md" {
+		t.Errorf("expected Reference/2025/Overview.md, got %s", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Getting Started", "files", "att1", "diagram.png")); err != nil {
+		t.Errorf("attachment not exported: %v", err)
+	}
+}
+
+// TestGetSpaceMetadata_SidebarWithoutTimestamps tests that updatedAt is read from the page
+// info when sidebar listings leave it out
+func TestGetSpaceMetadata_SidebarWithoutTimestamps(t *testing.T) {
+	client, fake := newClient(t, WithoutSidebarTimestamps())
+	space := findSpace(t, client, "engineering")
+
+	meta, err := client.GetSpaceMetadata(context.Background(), space)
+	if err != nil {
+		t.Fatalf("GetSpaceMetadata failed: %v", err)
+	}
+	var check func(pages []*docmost.PageMeta)
+	check = func(pages []*docmost.PageMeta) {
+		for _, p := range pages {
+			if p.UpdatedAt == "" {
+				t.Errorf("page %s has no updatedAt", p.Title)
+			}
+			check(p.Children)
+		}
+	}
+	check(meta.Pages)
+	if n := fake.Requests("/api/pages/info"); n != meta.TotalPages {
+		t.Errorf("expected %d page info requests, got %d", meta.TotalPages, n)
+	}
+}
+
+// TestGetSpaceMetadata_PageInfoFails tests that a failed updatedAt lookup fails the crawl
+// instead of leaving the page without a timestamp
+func TestGetSpaceMetadata_PageInfoFails(t *testing.T) {
+	client, fake := newClient(t, WithoutSidebarTimestamps())
+	space := findSpace(t, client, "engineering")
+	fake.Inject(Fault{Path: "/api/pages/info", Status: http.StatusNotFound})
+
+	if _, err := client.GetSpaceMetadata(context.Background(), space); err == nil {
+		t.Fatal("expected failed page info lookups to fail the crawl")
+	}
+}
+
+// TestExpiredSessionIsRenewed tests that the client logs in again when its session expires
+func TestExpiredSessionIsRenewed(t *testing.T) {
+	client, fake := newClient(t)
+
+	fake.ExpireSessions()
+	findSpace(t, client, "hr")
+
+	if logins := fake.Requests("/api/auth/login"); logins != 2 {
+		t.Errorf("expected 2 logins, got %d", logins)
+	}
+}
+
+// TestTransientErrorsAreRetried tests that rate-limited requests are retried
+func TestTransientErrorsAreRetried(t *testing.T) {
+	client, fake := newClient(t, WithFaults(Fault{Path: "/api/spaces/", Status: http.StatusTooManyRequests, RetryAfter: "0", Times: 2}))
+
+	findSpace(t, client, "engineering")
+
+	if n := fake.Requests("/api/spaces/"); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+// TestTruncatedExportFails tests that a truncated export ZIP fails the export
+func TestTruncatedExportFails(t *testing.T) {
+	client, fake := newClient(t)
+	space := findSpace(t, client, "engineering")
+	fake.Inject(Fault{Path: "/api/spaces/export", Truncate: true, Times: 1})
+
+	_, err := client.ExportSpace(context.Background(), space, filepath.Join(t.TempDir(), "engineering"))
+	if err == nil {
+		t.Fatal("expected truncated export to fail")
+	}
+}
+
+// TestExportSpace_TempFileOutsideOutput tests that the export ZIP is not downloaded next to
+// the destination, where an interrupted run would leave it behind
+func TestExportSpace_TempFileOutsideOutput(t *testing.T) {
+	client, fake := newClient(t)
+	space := findSpace(t, client, "engineering")
+	t.Setenv("TMPDIR", t.TempDir())
+	fake.Inject(Fault{Path: "/api/spaces/export", Delay: 200 * time.Millisecond, Times: 1})
+
+	parent := t.TempDir()
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.ExportSpace(context.Background(), space, filepath.Join(parent, "engineering"))
+		done <- err
+	}()
+
+	// The temp file exists once the export request has been sent
+	for fake.Requests("/api/spaces/export") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected nothing next to the destination during the download, got %s", entries[0].Name())
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ExportSpace failed: %v", err)
+	}
+}
+
+// TestSlowResponseHonoursDeadline tests that a slow response is abandoned at the context deadline
+func TestSlowResponseHonoursDeadline(t *testing.T) {
+	client, fake := newClient(t)
+	fake.Inject(Fault{Path: "/api/spaces/", Delay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.ListSpaces(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+// TestProbe tests the capabilities detected on the default fake server
+func TestProbe(t *testing.T) {
+	client, _ := newClient(t)
+
+	caps, err := client.Probe(context.Background())
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	want := docmost.Capabilities{Version: DefaultVersion, Enveloped: true, PageExport: true}
+	if *caps != want {
+		t.Errorf("expected %+v, got %+v", want, *caps)
+	}
+}
+
+// TestProbe_RejectsOldVersion tests that a server older than MinSupportedVersion is rejected
+func TestProbe_RejectsOldVersion(t *testing.T) {
+	client, _ := newClient(t, WithVersion("0.5.2"))
+
+	var versionErr *docmost.UnsupportedVersionError
+	if _, err := client.Probe(context.Background()); !errors.As(err, &versionErr) {
+		t.Fatalf("expected UnsupportedVersionError, got %v", err)
+	}
+	if versionErr.Version != "0.5.2" || versionErr.Minimum != docmost.MinSupportedVersion {
+		t.Errorf("unexpected error: %v", versionErr)
+	}
+}
+
+// TestProbe_WithoutPageExport tests probing a server without version endpoint and page export
+func TestProbe_WithoutPageExport(t *testing.T) {
+	client, _ := newClient(t, WithVersion(""), WithoutEndpoints("/api/pages/export"))
+
+	caps, err := client.Probe(context.Background())
+	if err != nil {
+		t.Fatalf("Probe failed: %v", err)
+	}
+	if caps.Version != "" || caps.PageExport {
+		t.Errorf("expected no version and no page export, got %+v", *caps)
+	}
+
+	space := findSpace(t, client, "engineering")
+	meta, err := client.GetSpaceMetadata(context.Background(), space)
+	if err != nil {
+		t.Fatalf("GetSpaceMetadata failed: %v", err)
+	}
+	if _, err := client.PatchSpace(context.Background(), meta, meta, t.TempDir()); !errors.Is(err, docmost.ErrPageExportUnsupported) {
+		t.Errorf("expected ErrPageExportUnsupported, got %v", err)
+	}
+}
+
+// TestParseFault tests parsing fault specifications of the fake server command line
+func TestParseFault(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Fault
+		wantErr bool
+	}{
+		{spec: "/api/spaces/export:truncate:1", want: Fault{Path: "/api/spaces/export", Truncate: true, Times: 1}},
+		{spec: "/api/pages/sidebar-pages:429:3", want: Fault{Path: "/api/pages/sidebar-pages", Status: 429, Times: 3}},
+		{spec: "/api/spaces/:slow=2s", want: Fault{Path: "/api/spaces/", Delay: 2 * time.Second}},
+		{spec: "/api/auth/login:401", want: Fault{Path: "/api/auth/login", Status: 401}},
+		{spec: "api/spaces/:500", wantErr: true},
+		{spec: "/api/spaces/:teapot", wantErr: true},
+		{spec: "/api/spaces/:500:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFault(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseFault(%q) expected error", tt.spec)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseFault(%q) = %+v, %v; want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Package fsutil holds file system helpers shared by the sources, the sync state and the commands
+package fsutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CopyFile copies src to dst, creating parent directories and replacing the content of
+// an existing dst
+func CopyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -1,22 +1,55 @@
 package health
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 )
 
+// Error kinds reported in Status.ErrorKind
+const (
+	ErrorKindAuth    = "auth"    // credentials or session rejected by the server
+	ErrorKindNetwork = "network" // server unreachable, connection reset, request timeout
+	ErrorKindSync    = "sync"    // any other failure
+)
+
 // Status represents the health check response
 type Status struct {
-	Status        string    `json:"status"`
-	LastSync      time.Time `json:"last_sync,omitempty"`
+	Status       string         `json:"status"`
+	LastSync     time.Time      `json:"last_sync,omitempty"`
+	LastError    string         `json:"last_error,omitempty"`
+	ErrorKind    string         `json:"error_kind,omitempty"`
+	SyncCount    int64          `json:"sync_count"`
+	IsRunning    bool           `json:"is_running"`
+	Uptime       string         `json:"uptime"`
+	NextSync     string         `json:"next_sync,omitempty"`
+	SyncInterval string         `json:"sync_interval,omitempty"`
+	Session      *SessionStatus `json:"session,omitempty"`
+
+	// Sources holds the status of each named source when several are configured
+	Sources map[string]SourceStatus `json:"sources,omitempty"`
+}
+
+// SourceStatus is the outcome of the last sync of one named source
+type SourceStatus struct {
+	Status    string         `json:"status"`
+	LastSync  time.Time      `json:"last_sync,omitempty"`
+	LastError string         `json:"last_error,omitempty"`
+	ErrorKind string         `json:"error_kind,omitempty"`
+	SyncCount int64          `json:"sync_count"`
+	Session   *SessionStatus `json:"session,omitempty"`
+}
+
+// SessionStatus describes the authentication session with the upstream server
+type SessionStatus struct {
+	Authenticated bool      `json:"authenticated"`
+	LastLogin     time.Time `json:"last_login,omitempty"`
+	Logins        int       `json:"logins"`
 	LastError     string    `json:"last_error,omitempty"`
-	SyncCount     int64     `json:"sync_count"`
-	IsRunning     bool      `json:"is_running"`
-	Uptime        string    `json:"uptime"`
-	NextSync      string    `json:"next_sync,omitempty"`
-	SyncInterval  string    `json:"sync_interval,omitempty"`
 }
 
 // Checker maintains health check state
@@ -28,6 +61,8 @@ type Checker struct {
 	isRunning     bool
 	startTime     time.Time
 	syncInterval  time.Duration
+	session       *SessionStatus
+	sources       map[string]*SourceStatus
 }
 
 // NewChecker creates a new health checker
@@ -48,6 +83,62 @@ func (c *Checker) UpdateSyncStatus(err error) {
 	c.syncCount++
 }
 
+// UpdateSession updates the authentication session state
+func (c *Checker) UpdateSession(session SessionStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session = &session
+}
+
+// UpdateSource records the outcome of syncing the named source.
+// session is nil for sources without a login session.
+func (c *Checker) UpdateSource(name string, err error, session *SessionStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sources == nil {
+		c.sources = make(map[string]*SourceStatus)
+	}
+	source, ok := c.sources[name]
+	if !ok {
+		source = &SourceStatus{}
+		c.sources[name] = source
+	}
+
+	source.Status = "healthy"
+	source.LastSync = time.Now()
+	source.LastError = ""
+	source.ErrorKind = ""
+	source.SyncCount++
+	source.Session = session
+	if err != nil {
+		source.Status = "degraded"
+		source.LastError = err.Error()
+		source.ErrorKind = ClassifyError(err)
+	}
+}
+
+// ClassifyError returns the ErrorKind of a sync error.
+// Authentication failures are recognized through an AuthFailure() method (see docmost.AuthError)
+// so that this package does not depend on a specific client.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var authErr interface{ AuthFailure() bool }
+	if errors.As(err, &authErr) && authErr.AuthFailure() {
+		return ErrorKindAuth
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindNetwork
+	}
+
+	return ErrorKindSync
+}
+
 // SetRunning sets the running state
 func (c *Checker) SetRunning(running bool) {
 	c.mu.Lock()
@@ -72,6 +163,19 @@ func (c *Checker) GetStatus() Status {
 	if c.lastSyncError != nil {
 		status.Status = "degraded"
 		status.LastError = c.lastSyncError.Error()
+		status.ErrorKind = ClassifyError(c.lastSyncError)
+	}
+
+	if c.session != nil {
+		session := *c.session
+		status.Session = &session
+	}
+
+	if len(c.sources) > 0 {
+		status.Sources = make(map[string]SourceStatus, len(c.sources))
+		for name, source := range c.sources {
+			status.Sources[name] = *source
+		}
 	}
 
 	// Calculate next sync time
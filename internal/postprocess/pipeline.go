@@ -0,0 +1,292 @@
+package postprocess
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Step is a named transformation of an exported space directory
+type Step struct {
+	Name        string
+	Description string
+
+	// Run transforms spaceDir in place. Problems that leave the output usable are
+	// reported with report.Warnf; a returned error is recorded as a warning as well.
+	Run func(spaceDir string, report *StepReport) error
+
+	// StopOnError skips the remaining steps if Run fails, for steps later ones depend on
+	StopOnError bool
+}
+
+// StepReport is the outcome of running a single step
+type StepReport struct {
+	Name     string         `json:"name"`
+	Duration time.Duration  `json:"duration"`
+	Changed  int            `json:"changed"` // files added, removed or modified by the step
+	Warnings []string       `json:"warnings,omitempty"`
+	Skipped  bool           `json:"skipped,omitempty"` // not run because an earlier step failed
+	Renames  []RenameResult `json:"renames,omitempty"` // set by the romanize step
+	Removed  []string       `json:"removed,omitempty"` // set by the remove-orphaned-files step
+}
+
+// Warnf records a warning of the step
+func (r *StepReport) Warnf(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// WarnFunc reports a problem that leaves the output usable, such as a file that could
+// not be renamed. Steps pass StepReport.Warnf so the warnings end up in the step report.
+type WarnFunc func(format string, args ...interface{})
+
+// Report is the outcome of running a pipeline on a space directory
+type Report struct {
+	Steps    []StepReport  `json:"steps"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Warnings returns the warnings of all steps, prefixed with the step name
+func (r *Report) Warnings() []string {
+	var warnings []string
+	for _, step := range r.Steps {
+		for _, w := range step.Warnings {
+			warnings = append(warnings, step.Name+": "+w)
+		}
+	}
+	return warnings
+}
+
+// registry holds the steps available to pipelines, by name
+var registry = make(map[string]Step)
+
+// Register makes step available to pipelines under its name.
+// It panics if the name is empty or already registered.
+func Register(step Step) {
+	if step.Name == "" || step.Run == nil {
+		panic("postprocess: step needs a name and a Run function")
+	}
+	if _, ok := registry[step.Name]; ok {
+		panic("postprocess: step registered twice: " + step.Name)
+	}
+	registry[step.Name] = step
+}
+
+// LookupStep returns the registered step with the given name
+func LookupStep(name string) (Step, bool) {
+	step, ok := registry[name]
+	return step, ok
+}
+
+// Steps returns all registered steps, sorted by name
+func Steps() []Step {
+	steps := make([]Step, 0, len(registry))
+	for _, step := range registry {
+		steps = append(steps, step)
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Name < steps[j].Name })
+	return steps
+}
+
+// defaultSteps is the pipeline converting a Docmost export to the Docusaurus format.
+// Some steps run twice because earlier renames can create new matches for them.
+var defaultSteps = []string{
+	"fix-slash-titles",
+	"remove-orphaned-files",
+	"wrap-placeholders",
+	"wrap-angle-brackets",
+	"wrap-raw-html",
+	"merge-slash-split-files", // before romanization, for Korean file names
+	"romanize",
+	"move-into-matching-folders",
+	"merge-korean-folders",
+	"rename-korean-folders",
+	"rename-korean-files",
+	"sanitize-special-characters",
+	"remove-space-before-extension",
+	"move-into-matching-folders", // sanitized names may now match a folder
+	"merge-slash-split-files",    // after romanization, for romanized file names
+	"cleanup-empty-dirs",
+}
+
+// DefaultSteps returns the names of the steps of the default pipeline, in order
+func DefaultSteps() []string {
+	return append([]string(nil), defaultSteps...)
+}
+
+// Pipeline runs a sequence of steps on a space directory
+type Pipeline struct {
+	steps []Step
+}
+
+// NewPipeline creates a pipeline of the named steps, in order, leaving out every step
+// named in disabled. An empty names selects DefaultSteps.
+func NewPipeline(names, disabled []string) (*Pipeline, error) {
+	if len(names) == 0 {
+		names = defaultSteps
+	}
+
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		if _, ok := registry[name]; !ok {
+			return nil, fmt.Errorf("unknown post-processing step %q", name)
+		}
+		skip[name] = true
+	}
+
+	p := &Pipeline{}
+	for _, name := range names {
+		step, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown post-processing step %q", name)
+		}
+		if !skip[name] {
+			p.steps = append(p.steps, step)
+		}
+	}
+	return p, nil
+}
+
+// StepNames returns the names of the steps of p, in order
+func (p *Pipeline) StepNames() []string {
+	names := make([]string, len(p.steps))
+	for i, step := range p.steps {
+		names[i] = step.Name
+	}
+	return names
+}
+
+// Run runs the steps on spaceDir in order. A failing step is reported and the pipeline
+// continues, unless the step is StopOnError, in which case the remaining steps are skipped.
+func (p *Pipeline) Run(spaceDir string) *Report {
+	report := &Report{Steps: make([]StepReport, 0, len(p.steps))}
+	start := time.Now()
+
+	before := snapshotFiles(spaceDir)
+	stopped := false
+	for _, step := range p.steps {
+		sr := StepReport{Name: step.Name}
+		if stopped {
+			sr.Skipped = true
+			report.Steps = append(report.Steps, sr)
+			continue
+		}
+
+		stepStart := time.Now()
+		err := step.Run(spaceDir, &sr)
+		sr.Duration = time.Since(stepStart)
+		if err != nil {
+			sr.Warnf("%v", err)
+			stopped = step.StopOnError
+		}
+
+		after := snapshotFiles(spaceDir)
+		sr.Changed = countChanges(before, after)
+		before = after
+
+		report.Steps = append(report.Steps, sr)
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// fileStamp identifies a version of a file for detecting changes
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// snapshotFiles returns the stamps of all regular files below dir, by relative path
+func snapshotFiles(dir string) map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files
+}
+
+// countChanges counts the files added, removed or modified between two snapshots.
+// A renamed file counts as removed and added.
+func countChanges(before, after map[string]fileStamp) int {
+	changed := 0
+	for path, stamp := range after {
+		if prev, ok := before[path]; !ok || prev.size != stamp.size || !prev.modTime.Equal(stamp.modTime) {
+			changed++
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed++
+		}
+	}
+	return changed
+}
+
+// simpleStep adapts a function reporting warnings and an error to Step.Run
+func simpleStep(fn func(spaceDir string, warn WarnFunc) error) func(string, *StepReport) error {
+	return func(spaceDir string, report *StepReport) error {
+		return fn(spaceDir, report.Warnf)
+	}
+}
+
+// romanizeStep runs RomanizeSpace and keeps its renames in the report
+func romanizeStep(spaceDir string, report *StepReport) error {
+	results, err := RomanizeSpace(spaceDir, report.Warnf)
+	report.Renames = results
+	return err
+}
+
+// removeOrphanedStep runs RemoveOrphanedFiles and keeps the removed files in the report
+func removeOrphanedStep(spaceDir string, report *StepReport) error {
+	removed, err := RemoveOrphanedFiles(spaceDir, report.Warnf)
+	report.Removed = removed
+	return err
+}
+
+func init() {
+	for _, step := range []Step{
+		{Name: "fix-slash-titles", Run: simpleStep(FixSlashInTitles),
+			Description: "Fix files and folders split by a \"/\" in the page title and update _metadata.json"},
+		{Name: "remove-orphaned-files", Run: removeOrphanedStep,
+			Description: "Remove Markdown files that are not pages of _metadata.json"},
+		{Name: "remove-untitled-files", Run: simpleStep(RemoveUntitledFiles),
+			Description: "Remove empty \"untitled\" placeholder pages"},
+		{Name: "wrap-placeholders", Run: simpleStep(WrapPlaceholdersWithBackticks),
+			Description: "Wrap {placeholders} with backticks"},
+		{Name: "wrap-angle-brackets", Run: simpleStep(WrapAngleBracketsWithBackticks),
+			Description: "Wrap <angle brackets> with backticks"},
+		{Name: "wrap-raw-html", Run: simpleStep(WrapRawHTMLWithCodeBlock),
+			Description: "Wrap raw HTML such as tables with code blocks"},
+		{Name: "merge-slash-split-files", Run: simpleStep(MergeSlashSplitFiles),
+			Description: "Merge files split into folders by a \"/\" in the title"},
+		{Name: "romanize", Run: romanizeStep, StopOnError: true,
+			Description: "Romanize Korean file names from _metadata.json and add frontmatter"},
+		{Name: "move-into-matching-folders", Run: simpleStep(MoveFilesIntoMatchingFolders),
+			Description: "Move files into the folder of the same name (e.g. a.md -> a/a.md)"},
+		{Name: "merge-korean-folders", Run: simpleStep(MergeKoreanFoldersIntoRomanized),
+			Description: "Merge Korean folders into their romanized folders"},
+		{Name: "rename-korean-folders", Run: simpleStep(RenameRemainingKoreanFolders),
+			Description: "Romanize the remaining Korean folder names"},
+		{Name: "rename-korean-files", Run: simpleStep(RenameRemainingKoreanFiles),
+			Description: "Romanize the remaining Korean .md file names"},
+		{Name: "sanitize-special-characters", Run: simpleStep(SanitizeSpecialCharacters),
+			Description: "Replace special characters in folder and .md file names (e.g. & -> -and-)"},
+		{Name: "remove-space-before-extension", Run: simpleStep(RemoveSpaceBeforeExtension),
+			Description: "Remove spaces before .md extensions"},
+		{Name: "cleanup-empty-dirs", Run: simpleStep(CleanupEmptyDirs),
+			Description: "Remove empty directories"},
+	} {
+		Register(step)
+	}
+}
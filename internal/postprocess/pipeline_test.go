@@ -0,0 +1,113 @@
+package postprocess
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jung/doc2git/internal/hangul"
+)
+
+// TestNewPipeline tests building pipelines from step names, disabled steps and unknown names
+func TestNewPipeline(t *testing.T) {
+	p, err := NewPipeline(nil, nil)
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	if !reflect.DeepEqual(p.StepNames(), DefaultSteps()) {
+		t.Errorf("expected the default steps, got %v", p.StepNames())
+	}
+
+	p, err = NewPipeline([]string{"wrap-placeholders", "remove-untitled-files", "cleanup-empty-dirs"}, []string{"cleanup-empty-dirs"})
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	if got := p.StepNames(); !reflect.DeepEqual(got, []string{"wrap-placeholders", "remove-untitled-files"}) {
+		t.Errorf("unexpected steps %v", got)
+	}
+
+	p, _ = NewPipeline(nil, []string{"romanize"})
+	for _, name := range p.StepNames() {
+		if name == "romanize" {
+			t.Error("disabled step is still in the default pipeline")
+		}
+	}
+
+	if _, err := NewPipeline([]string{"no-such-step"}, nil); err == nil {
+		t.Error("expected an error for an unknown step")
+	}
+	if _, err := NewPipeline(nil, []string{"no-such-step"}); err == nil {
+		t.Error("expected an error for an unknown disabled step")
+	}
+}
+
+// TestPipeline_Run tests the changes, warnings and skipped steps recorded by a pipeline run
+func TestPipeline_Run(t *testing.T) {
+	Register(Step{Name: "test-add-file", Run: func(spaceDir string, report *StepReport) error {
+		report.Warnf("wrote %s", "new.md")
+		return os.WriteFile(filepath.Join(spaceDir, "new.md"), []byte("# New\n"), 0644)
+	}})
+	Register(Step{Name: "test-rename-file", Run: func(spaceDir string, _ *StepReport) error {
+		return os.Rename(filepath.Join(spaceDir, "a.md"), filepath.Join(spaceDir, "b.md"))
+	}})
+	Register(Step{Name: "test-fail", StopOnError: true, Run: func(string, *StepReport) error {
+		return errors.New("broken")
+	}})
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.md"), []byte("# A\n"), 0644)
+
+	p, err := NewPipeline([]string{"test-add-file", "test-rename-file", "test-fail", "test-add-file"}, nil)
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	report := p.Run(dir)
+
+	if len(report.Steps) != 4 {
+		t.Fatalf("expected 4 step reports, got %d", len(report.Steps))
+	}
+	add, rename, fail, skipped := report.Steps[0], report.Steps[1], report.Steps[2], report.Steps[3]
+	if add.Changed != 1 || len(add.Warnings) != 1 {
+		t.Errorf("add step: expected 1 change and 1 warning, got %+v", add)
+	}
+	if rename.Changed != 2 {
+		t.Errorf("rename step: expected a removed and an added file, got %d changes", rename.Changed)
+	}
+	if fail.Changed != 0 || len(fail.Warnings) != 1 || fail.Skipped {
+		t.Errorf("failing step: expected its error as the only warning, got %+v", fail)
+	}
+	if !skipped.Skipped {
+		t.Error("expected the step after a failed StopOnError step to be skipped")
+	}
+	if got := report.Warnings(); len(got) != 2 || got[1] != "test-fail: broken" {
+		t.Errorf("unexpected warnings %v", got)
+	}
+}
+
+// TestPipeline_StepWarnings tests that problems with single files end up as warnings in
+// the report of the step that hit them
+func TestPipeline_StepWarnings(t *testing.T) {
+	dir := t.TempDir()
+	romanized := hangul.Romanize("한글") + ".md"
+	for _, name := range []string{"한글.md", romanized} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("# page"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := NewPipeline([]string{"rename-korean-files", "cleanup-empty-dirs"}, nil)
+	if err != nil {
+		t.Fatalf("NewPipeline failed: %v", err)
+	}
+	report := p.Run(dir)
+
+	if warnings := report.Steps[0].Warnings; len(warnings) != 1 || !strings.Contains(warnings[0], romanized) {
+		t.Errorf("expected a warning about the existing %s, got %v", romanized, warnings)
+	}
+	if warnings := report.Steps[1].Warnings; len(warnings) != 0 {
+		t.Errorf("expected no warnings from cleanup-empty-dirs, got %v", warnings)
+	}
+}
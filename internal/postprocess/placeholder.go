@@ -10,7 +10,7 @@ import (
 // WrapPlaceholdersWithBackticks searches for {placeholder} patterns in markdown files
 // and wraps them with backticks: {text} -> `{text}`
 // It skips patterns that are already wrapped with backticks.
-func WrapPlaceholdersWithBackticks(spaceDir string) error {
+func WrapPlaceholdersWithBackticks(spaceDir string, warn WarnFunc) error {
 	return filepath.Walk(spaceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -24,7 +24,7 @@ func WrapPlaceholdersWithBackticks(spaceDir string) error {
 		// Read file content
 		content, err := os.ReadFile(path)
 		if err != nil {
-			fmt.Printf("Warning: failed to read file %s: %v\n", path, err)
+			warn("failed to read file %s: %v", path, err)
 			return nil
 		}
 
@@ -34,7 +34,7 @@ func WrapPlaceholdersWithBackticks(spaceDir string) error {
 		// Only write if content changed
 		if newContent != string(content) {
 			if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
-				fmt.Printf("Warning: failed to write file %s: %v\n", path, err)
+				warn("failed to write file %s: %v", path, err)
 				return nil
 			}
 			fmt.Printf("  Updated placeholders in: %s\n", path)
@@ -47,7 +47,7 @@ func WrapPlaceholdersWithBackticks(spaceDir string) error {
 // WrapAngleBracketsWithBackticks searches for <> patterns in markdown files
 // and wraps them with backticks: <> -> `<>`, </> -> `</>`
 // It skips patterns that are already wrapped with backticks or inside code blocks.
-func WrapAngleBracketsWithBackticks(spaceDir string) error {
+func WrapAngleBracketsWithBackticks(spaceDir string, warn WarnFunc) error {
 	return filepath.Walk(spaceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -61,7 +61,7 @@ func WrapAngleBracketsWithBackticks(spaceDir string) error {
 		// Read file content
 		content, err := os.ReadFile(path)
 		if err != nil {
-			fmt.Printf("Warning: failed to read file %s: %v\n", path, err)
+			warn("failed to read file %s: %v", path, err)
 			return nil
 		}
 
@@ -71,7 +71,7 @@ func WrapAngleBracketsWithBackticks(spaceDir string) error {
 		// Only write if content changed
 		if newContent != string(content) {
 			if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
-				fmt.Printf("Warning: failed to write file %s: %v\n", path, err)
+				warn("failed to write file %s: %v", path, err)
 				return nil
 			}
 			fmt.Printf("  Updated angle brackets in: %s\n", path)
@@ -164,7 +164,7 @@ func wrapAngleBrackets(content string) string {
 
 // WrapRawHTMLWithCodeBlock searches for raw HTML (like <table>, <tbody>, etc.) in markdown files
 // that are not already inside code blocks and wraps them with triple backticks.
-func WrapRawHTMLWithCodeBlock(spaceDir string) error {
+func WrapRawHTMLWithCodeBlock(spaceDir string, warn WarnFunc) error {
 	return filepath.Walk(spaceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -178,7 +178,7 @@ func WrapRawHTMLWithCodeBlock(spaceDir string) error {
 		// Read file content
 		content, err := os.ReadFile(path)
 		if err != nil {
-			fmt.Printf("Warning: failed to read file %s: %v\n", path, err)
+			warn("failed to read file %s: %v", path, err)
 			return nil
 		}
 
@@ -188,7 +188,7 @@ func WrapRawHTMLWithCodeBlock(spaceDir string) error {
 		// Only write if content changed
 		if newContent != string(content) {
 			if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
-				fmt.Printf("Warning: failed to write file %s: %v\n", path, err)
+				warn("failed to write file %s: %v", path, err)
 				return nil
 			}
 			fmt.Printf("  Wrapped raw HTML in: %s\n", path)
@@ -753,7 +753,7 @@ return <><Child /></>;
 	}
 
 	// Run function
-	if err := WrapAngleBracketsWithBackticks(tempDir); err != nil {
+	if err := WrapAngleBracketsWithBackticks(tempDir, t.Logf); err != nil {
 		t.Fatalf("WrapAngleBracketsWithBackticks failed: %v", err)
 	}
 
@@ -920,7 +920,7 @@ func TestWrapRawHTMLWithCodeBlock_Integration(t *testing.T) {
 	}
 
 	// Run function
-	if err := WrapRawHTMLWithCodeBlock(tempDir); err != nil {
+	if err := WrapRawHTMLWithCodeBlock(tempDir, t.Logf); err != nil {
 		t.Fatalf("WrapRawHTMLWithCodeBlock failed: %v", err)
 	}
 
@@ -957,7 +957,7 @@ func TestWrapPlaceholdersWithBackticks_Integration(t *testing.T) {
 	}
 
 	// Run function
-	if err := WrapPlaceholdersWithBackticks(tempDir); err != nil {
+	if err := WrapPlaceholdersWithBackticks(tempDir, t.Logf); err != nil {
 		t.Fatalf("WrapPlaceholdersWithBackticks failed: %v", err)
 	}
 
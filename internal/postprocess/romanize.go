@@ -22,6 +22,7 @@ type PageMeta struct {
 	HasChildren  bool        `json:"hasChildren"`
 	Children     []*PageMeta `json:"children,omitempty"`
 	FilePath     string      `json:"filePath,omitempty"`
+	UpdatedAt    string      `json:"updatedAt,omitempty"`
 }
 
 // SpaceMeta represents metadata for a space including page tree structure
@@ -34,6 +35,17 @@ type SpaceMeta struct {
 	UpdatedAt   string      `json:"updatedAt"`
 	Pages       []*PageMeta `json:"pages"`
 	TotalPages  int         `json:"totalPages"`
+	RootPageID  string      `json:"rootPageId,omitempty"`
+	FileIssues  []FileIssue `json:"fileIssues,omitempty"`
+}
+
+// FileIssue describes a page whose exported file could not be identified unambiguously
+type FileIssue struct {
+	PageID     string   `json:"pageId"`
+	Title      string   `json:"title"`
+	Kind       string   `json:"kind"`
+	FilePath   string   `json:"filePath,omitempty"`
+	Candidates []string `json:"candidates,omitempty"`
 }
 
 // RenameResult contains the result of renaming operation
@@ -45,7 +57,7 @@ type RenameResult struct {
 }
 
 // RomanizeSpace reads _metadata.json and renames Korean files/folders to romanized names
-func RomanizeSpace(spaceDir string) ([]RenameResult, error) {
+func RomanizeSpace(spaceDir string, warn WarnFunc) ([]RenameResult, error) {
 	metaPath := filepath.Join(spaceDir, "_metadata.json")
 
 	// Read metadata file
@@ -63,9 +75,9 @@ func RomanizeSpace(spaceDir string) ([]RenameResult, error) {
 
 	// Process all pages recursively with sidebar position
 	for i, page := range spaceMeta.Pages {
-		pageResults, err := processPage(spaceDir, page, "", i+1)
+		pageResults, err := processPage(spaceDir, page, "", i+1, warn)
 		if err != nil {
-			fmt.Printf("Warning: failed to process page %s: %v\n", page.Title, err)
+			warn("failed to process page %s: %v", page.Title, err)
 			continue
 		}
 		results = append(results, pageResults...)
@@ -75,16 +87,16 @@ func RomanizeSpace(spaceDir string) ([]RenameResult, error) {
 }
 
 // processPage processes a single page and its children
-func processPage(spaceDir string, page *PageMeta, parentRomanizedDir string, sidebarPosition int) ([]RenameResult, error) {
+func processPage(spaceDir string, page *PageMeta, parentRomanizedDir string, sidebarPosition int, warn WarnFunc) ([]RenameResult, error) {
 	var results []RenameResult
 
 	if page.FilePath == "" {
 		// Process children even if this page has no file
 		if page.HasChildren && len(page.Children) > 0 {
 			for i, child := range page.Children {
-				childResults, err := processPage(spaceDir, child, parentRomanizedDir, i+1)
+				childResults, err := processPage(spaceDir, child, parentRomanizedDir, i+1, warn)
 				if err != nil {
-					fmt.Printf("Warning: failed to process child page %s: %v\n", child.Title, err)
+					warn("failed to process child page %s: %v", child.Title, err)
 					continue
 				}
 				results = append(results, childResults...)
@@ -97,7 +109,7 @@ func processPage(spaceDir string, page *PageMeta, parentRomanizedDir string, sid
 
 	// Check if file exists
 	if _, err := os.Stat(originalPath); os.IsNotExist(err) {
-		fmt.Printf("Warning: file not found: %s\n", originalPath)
+		warn("file not found: %s", originalPath)
 		return results, nil
 	}
 
@@ -154,8 +166,8 @@ func processPage(spaceDir string, page *PageMeta, parentRomanizedDir string, sid
 				destFilesDir := filepath.Join(newDir, "files")
 				// Copy/merge files folder (copyFilesToDestination handles existing files)
 				fmt.Printf("  Copying files folder for moved MD: %s -> %s\n", sourceFilesDir, destFilesDir)
-				if err := copyFilesToDestination(sourceFilesDir, destFilesDir); err != nil {
-					fmt.Printf("Warning: failed to copy files folder: %v\n", err)
+				if err := copyFilesToDestination(sourceFilesDir, destFilesDir, warn); err != nil {
+					warn("failed to copy files folder: %v", err)
 				}
 			}
 		}
@@ -164,7 +176,7 @@ func processPage(spaceDir string, page *PageMeta, parentRomanizedDir string, sid
 	// Remove original file if it's different from the new path
 	if originalPath != romanizedFullPath {
 		if err := os.Remove(originalPath); err != nil {
-			fmt.Printf("Warning: failed to remove original file %s: %v\n", originalPath, err)
+			warn("failed to remove original file %s: %v", originalPath, err)
 		}
 	}
 
@@ -173,9 +185,9 @@ func processPage(spaceDir string, page *PageMeta, parentRomanizedDir string, sid
 	// Process children
 	if page.HasChildren && len(page.Children) > 0 {
 		for i, child := range page.Children {
-			childResults, err := processPage(spaceDir, child, currentRomanizedDir, i+1)
+			childResults, err := processPage(spaceDir, child, currentRomanizedDir, i+1, warn)
 			if err != nil {
-				fmt.Printf("Warning: failed to process child page %s: %v\n", child.Title, err)
+				warn("failed to process child page %s: %v", child.Title, err)
 				continue
 			}
 			results = append(results, childResults...)
@@ -239,7 +251,7 @@ func addFrontmatter(content string, title string, sidebarPosition int) string {
 // MoveFilesIntoMatchingFolders moves files into folders when both share the same name
 // e.g., meomeideu.md and meomeideu/ folder exist at same level -> move meomeideu.md into meomeideu/
 // Also copies the files/ folder contents from the same level into the target folder's files/
-func MoveFilesIntoMatchingFolders(spaceDir string) error {
+func MoveFilesIntoMatchingFolders(spaceDir string, warn WarnFunc) error {
 	// Collect all directories first
 	var dirs []string
 	err := filepath.Walk(spaceDir, func(path string, info os.FileInfo, err error) error {
@@ -268,7 +280,7 @@ func MoveFilesIntoMatchingFolders(spaceDir string) error {
 
 			// Check if destination already exists
 			if _, err := os.Stat(newPath); err == nil {
-				fmt.Printf("Warning: destination already exists, skipping: %s\n", newPath)
+				warn("destination already exists, skipping: %s", newPath)
 				continue
 			}
 
@@ -278,14 +290,14 @@ func MoveFilesIntoMatchingFolders(spaceDir string) error {
 			if info, err := os.Stat(sourceFilesDir); err == nil && info.IsDir() {
 				destFilesDir := filepath.Join(dir, "files")
 				fmt.Printf("  Copying files folder for %s: %s -> %s\n", dirName+".md", sourceFilesDir, destFilesDir)
-				if err := copyFilesToDestination(sourceFilesDir, destFilesDir); err != nil {
-					fmt.Printf("Warning: failed to copy files folder: %v\n", err)
+				if err := copyFilesToDestination(sourceFilesDir, destFilesDir, warn); err != nil {
+					warn("failed to copy files folder: %v", err)
 				}
 			}
 
 			// Move the file
 			if err := os.Rename(matchingFile, newPath); err != nil {
-				fmt.Printf("Warning: failed to move file %s to %s: %v\n", matchingFile, newPath, err)
+				warn("failed to move file %s to %s: %v", matchingFile, newPath, err)
 				continue
 			}
 			fmt.Printf("  Moved: %s -> %s\n", matchingFile, newPath)
@@ -297,7 +309,7 @@ func MoveFilesIntoMatchingFolders(spaceDir string) error {
 
 // copyFilesToDestination copies files from source files/ folder to destination files/ folder
 // If destination files/ folder exists, it merges the contents (does not overwrite existing files)
-func copyFilesToDestination(srcDir, dstDir string) error {
+func copyFilesToDestination(srcDir, dstDir string, warn WarnFunc) error {
 	// Create destination directory if it doesn't exist
 	if err := os.MkdirAll(dstDir, 0755); err != nil {
 		return fmt.Errorf("failed to create destination directory: %w", err)
@@ -314,8 +326,8 @@ func copyFilesToDestination(srcDir, dstDir string) error {
 
 		if entry.IsDir() {
 			// Recursively copy subdirectories
-			if err := copyFilesToDestination(srcPath, dstPath); err != nil {
-				fmt.Printf("    Warning: failed to copy subdirectory %s: %v\n", srcPath, err)
+			if err := copyFilesToDestination(srcPath, dstPath, warn); err != nil {
+				warn("failed to copy subdirectory %s: %v", srcPath, err)
 			}
 		} else {
 			// Check if destination file already exists
@@ -327,12 +339,12 @@ func copyFilesToDestination(srcDir, dstDir string) error {
 			// Copy the file
 			srcContent, err := os.ReadFile(srcPath)
 			if err != nil {
-				fmt.Printf("    Warning: failed to read file %s: %v\n", srcPath, err)
+				warn("failed to read file %s: %v", srcPath, err)
 				continue
 			}
 
 			if err := os.WriteFile(dstPath, srcContent, 0644); err != nil {
-				fmt.Printf("    Warning: failed to write file %s: %v\n", dstPath, err)
+				warn("failed to write file %s: %v", dstPath, err)
 				continue
 			}
 			fmt.Printf("    Copied: %s -> %s\n", srcPath, dstPath)
@@ -344,7 +356,7 @@ func copyFilesToDestination(srcDir, dstDir string) error {
 
 // MergeKoreanFoldersIntoRomanized moves contents from Korean-named folders into their romanized counterparts
 // e.g., 머메이드/files/ -> meomeideu/files/ when both 머메이드/ and meomeideu/ exist
-func MergeKoreanFoldersIntoRomanized(spaceDir string) error {
+func MergeKoreanFoldersIntoRomanized(spaceDir string, warn WarnFunc) error {
 	// Collect all directories at each level
 	dirsByParent := make(map[string][]string)
 
@@ -408,14 +420,14 @@ func MergeKoreanFoldersIntoRomanized(spaceDir string) error {
 				// Both Korean and romanized folders exist, merge contents
 				fmt.Printf("  Merging Korean folder contents: %s -> %s\n", koreanDir, romanizedDir)
 
-				if err := mergeDirectoryContents(koreanDir, romanizedDir); err != nil {
-					fmt.Printf("Warning: failed to merge %s into %s: %v\n", koreanDir, romanizedDir, err)
+				if err := mergeDirectoryContents(koreanDir, romanizedDir, warn); err != nil {
+					warn("failed to merge %s into %s: %v", koreanDir, romanizedDir, err)
 					continue
 				}
 
 				// Remove the now-empty Korean folder
 				if err := os.RemoveAll(koreanDir); err != nil {
-					fmt.Printf("Warning: failed to remove Korean folder %s: %v\n", koreanDir, err)
+					warn("failed to remove Korean folder %s: %v", koreanDir, err)
 				}
 			}
 		}
@@ -436,7 +448,7 @@ func containsKorean(s string) bool {
 }
 
 // mergeDirectoryContents moves all contents from src directory to dst directory
-func mergeDirectoryContents(src, dst string) error {
+func mergeDirectoryContents(src, dst string, warn WarnFunc) error {
 	entries, err := os.ReadDir(src)
 	if err != nil {
 		return fmt.Errorf("failed to read source directory: %w", err)
@@ -456,7 +468,7 @@ func mergeDirectoryContents(src, dst string) error {
 				if entry.Name() == "files" {
 					fmt.Printf("    [files folder] Merging into existing: %s -> %s\n", srcPath, dstPath)
 				}
-				if err := mergeDirectoryContents(srcPath, dstPath); err != nil {
+				if err := mergeDirectoryContents(srcPath, dstPath, warn); err != nil {
 					return err
 				}
 				// Remove source directory after merging
@@ -482,7 +494,7 @@ func mergeDirectoryContents(src, dst string) error {
 			if _, err := os.Stat(dstPath); err == nil {
 				// File already exists at destination, remove source file (keep destination)
 				if err := os.Remove(srcPath); err != nil {
-					fmt.Printf("    Warning: failed to remove duplicate source file %s: %v\n", srcPath, err)
+					warn("failed to remove duplicate source file %s: %v", srcPath, err)
 				}
 				continue
 			}
@@ -499,7 +511,7 @@ func mergeDirectoryContents(src, dst string) error {
 
 // RenameRemainingKoreanFolders renames any remaining Korean-named folders to romanized names
 // This handles folders that weren't merged because no romanized counterpart existed
-func RenameRemainingKoreanFolders(spaceDir string) error {
+func RenameRemainingKoreanFolders(spaceDir string, warn WarnFunc) error {
 	// We need to process from deepest to shallowest, so collect all Korean folders first
 	var koreanFolders []string
 
@@ -547,18 +559,18 @@ func RenameRemainingKoreanFolders(spaceDir string) error {
 		// If romanized folder already exists, merge into it
 		if _, err := os.Stat(romanizedPath); err == nil {
 			fmt.Printf("  Merging remaining Korean folder: %s -> %s\n", koreanFolder, romanizedPath)
-			if err := mergeDirectoryContents(koreanFolder, romanizedPath); err != nil {
-				fmt.Printf("Warning: failed to merge %s into %s: %v\n", koreanFolder, romanizedPath, err)
+			if err := mergeDirectoryContents(koreanFolder, romanizedPath, warn); err != nil {
+				warn("failed to merge %s into %s: %v", koreanFolder, romanizedPath, err)
 				continue
 			}
 			if err := os.RemoveAll(koreanFolder); err != nil {
-				fmt.Printf("Warning: failed to remove Korean folder %s: %v\n", koreanFolder, err)
+				warn("failed to remove Korean folder %s: %v", koreanFolder, err)
 			}
 		} else {
 			// Romanized folder doesn't exist, just rename
 			fmt.Printf("  Renaming Korean folder: %s -> %s\n", koreanFolder, romanizedPath)
 			if err := os.Rename(koreanFolder, romanizedPath); err != nil {
-				fmt.Printf("Warning: failed to rename %s to %s: %v\n", koreanFolder, romanizedPath, err)
+				warn("failed to rename %s to %s: %v", koreanFolder, romanizedPath, err)
 			}
 		}
 	}
@@ -568,7 +580,7 @@ func RenameRemainingKoreanFolders(spaceDir string) error {
 
 // RenameRemainingKoreanFiles renames any remaining Korean-named .md files to romanized names
 // This handles files that weren't processed by RomanizeSpace (not in _metadata.json)
-func RenameRemainingKoreanFiles(spaceDir string) error {
+func RenameRemainingKoreanFiles(spaceDir string, warn WarnFunc) error {
 	var koreanFiles []string
 
 	err := filepath.Walk(spaceDir, func(path string, info os.FileInfo, err error) error {
@@ -611,13 +623,13 @@ func RenameRemainingKoreanFiles(spaceDir string) error {
 
 		// Check if destination exists
 		if _, err := os.Stat(romanizedPath); err == nil {
-			fmt.Printf("Warning: romanized file already exists, skipping: %s\n", romanizedPath)
+			warn("romanized file already exists, skipping: %s", romanizedPath)
 			continue
 		}
 
 		fmt.Printf("  Renaming Korean file: %s -> %s\n", koreanFile, romanizedPath)
 		if err := os.Rename(koreanFile, romanizedPath); err != nil {
-			fmt.Printf("Warning: failed to rename %s to %s: %v\n", koreanFile, romanizedPath, err)
+			warn("failed to rename %s to %s: %v", koreanFile, romanizedPath, err)
 		}
 	}
 
@@ -625,7 +637,7 @@ func RenameRemainingKoreanFiles(spaceDir string) error {
 }
 
 // CleanupEmptyDirs removes empty directories after renaming
-func CleanupEmptyDirs(spaceDir string) error {
+func CleanupEmptyDirs(spaceDir string, warn WarnFunc) error {
 	return filepath.Walk(spaceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -647,7 +659,7 @@ func CleanupEmptyDirs(spaceDir string) error {
 
 		if len(entries) == 0 {
 			if err := os.Remove(path); err != nil {
-				fmt.Printf("Warning: failed to remove empty directory %s: %v\n", path, err)
+				warn("failed to remove empty directory %s: %v", path, err)
 			}
 		}
 
@@ -39,7 +39,7 @@ func TestMoveFilesIntoMatchingFolders_SingleLevel(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -111,7 +111,7 @@ func TestMoveFilesIntoMatchingFolders_TwoLevels(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -189,7 +189,7 @@ func TestMoveFilesIntoMatchingFolders_ThreeLevels(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -281,7 +281,7 @@ func TestMoveFilesIntoMatchingFolders_NoMatchingFolder(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -326,7 +326,7 @@ func TestMoveFilesIntoMatchingFolders_DestinationExists(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -380,7 +380,7 @@ func TestMoveFilesIntoMatchingFolders_MixedScenario(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -428,7 +428,7 @@ func TestMoveFilesIntoMatchingFolders_EmptyDir(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	// Run function on empty directory
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed on empty dir: %v", err)
 	}
 }
@@ -475,7 +475,7 @@ func TestMergeKoreanFoldersIntoRomanized_Basic(t *testing.T) {
 	}
 
 	// Run function
-	if err := MergeKoreanFoldersIntoRomanized(tempDir); err != nil {
+	if err := MergeKoreanFoldersIntoRomanized(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeKoreanFoldersIntoRomanized failed: %v", err)
 	}
 
@@ -565,7 +565,7 @@ func TestMergeKoreanFoldersIntoRomanized_MultipleFiles(t *testing.T) {
 	}
 
 	// Run function
-	if err := MergeKoreanFoldersIntoRomanized(tempDir); err != nil {
+	if err := MergeKoreanFoldersIntoRomanized(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeKoreanFoldersIntoRomanized failed: %v", err)
 	}
 
@@ -613,7 +613,7 @@ func TestMergeKoreanFoldersIntoRomanized_NoRomanizedFolder(t *testing.T) {
 	}
 
 	// Run function
-	if err := MergeKoreanFoldersIntoRomanized(tempDir); err != nil {
+	if err := MergeKoreanFoldersIntoRomanized(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeKoreanFoldersIntoRomanized failed: %v", err)
 	}
 
@@ -674,7 +674,7 @@ func TestMoveFilesIntoMatchingFolders_WithFilesFolder(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -773,7 +773,7 @@ func TestMoveFilesIntoMatchingFolders_FilesFolderMerge(t *testing.T) {
 	}
 
 	// Run function
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -826,7 +826,7 @@ func TestMoveFilesIntoMatchingFolders_NoFilesFolder(t *testing.T) {
 	}
 
 	// Run function - should not fail even without files/ folder
-	if err := MoveFilesIntoMatchingFolders(tempDir); err != nil {
+	if err := MoveFilesIntoMatchingFolders(tempDir, t.Logf); err != nil {
 		t.Fatalf("MoveFilesIntoMatchingFolders failed: %v", err)
 	}
 
@@ -14,7 +14,7 @@ import (
 // SanitizeSpecialCharacters renames folders and .md files that contain special characters
 // that could break Docusaurus. Non-.md files keep their original names.
 // Special characters like &, +, (, ), etc. are replaced with safe alternatives.
-func SanitizeSpecialCharacters(spaceDir string) error {
+func SanitizeSpecialCharacters(spaceDir string, warn WarnFunc) error {
 	// Collect all paths that need sanitizing (folders and .md files)
 	var pathsToSanitize []string
 
@@ -76,21 +76,21 @@ func SanitizeSpecialCharacters(spaceDir string) error {
 			info, _ := os.Stat(oldPath)
 			if info.IsDir() {
 				fmt.Printf("  Merging sanitized folder: %s -> %s\n", oldPath, newPath)
-				if err := mergeDirectoryContents(oldPath, newPath); err != nil {
-					fmt.Printf("Warning: failed to merge %s into %s: %v\n", oldPath, newPath, err)
+				if err := mergeDirectoryContents(oldPath, newPath, warn); err != nil {
+					warn("failed to merge %s into %s: %v", oldPath, newPath, err)
 					continue
 				}
 				if err := os.RemoveAll(oldPath); err != nil {
-					fmt.Printf("Warning: failed to remove folder %s: %v\n", oldPath, err)
+					warn("failed to remove folder %s: %v", oldPath, err)
 				}
 			} else {
-				fmt.Printf("Warning: sanitized file already exists, skipping: %s\n", newPath)
+				warn("sanitized file already exists, skipping: %s", newPath)
 			}
 		} else {
 			// Destination doesn't exist, just rename
 			fmt.Printf("  Sanitizing: %s -> %s\n", oldPath, newPath)
 			if err := os.Rename(oldPath, newPath); err != nil {
-				fmt.Printf("Warning: failed to rename %s to %s: %v\n", oldPath, newPath, err)
+				warn("failed to rename %s to %s: %v", oldPath, newPath, err)
 			}
 		}
 	}
@@ -183,7 +183,7 @@ func sanitizeName(name string) string {
 //	      └── inga-gwanryeon-gongtong-ereo-peiji.md
 //	Expected (after this fix):
 //	  └── Security365-hwangyeong-injeung-inga-gwanryeon-gongtong-ereo-peiji.md
-func MergeSlashSplitFiles(spaceDir string) error {
+func MergeSlashSplitFiles(spaceDir string, warn WarnFunc) error {
 	metaPath := filepath.Join(spaceDir, "_metadata.json")
 
 	// Read metadata file
@@ -201,11 +201,11 @@ func MergeSlashSplitFiles(spaceDir string) error {
 	slashPages := findPagesWithSlashInTitle(spaceMeta.Pages)
 
 	for _, page := range slashPages {
-		if err := mergeSlashSplitFile(spaceDir, page, false); err != nil {
-			fmt.Printf("Warning: failed to merge slash-split file (Korean) for '%s': %v\n", page.Title, err)
+		if err := mergeSlashSplitFile(spaceDir, page, false, warn); err != nil {
+			warn("failed to merge slash-split file (Korean) for '%s': %v", page.Title, err)
 		}
-		if err := mergeSlashSplitFile(spaceDir, page, true); err != nil {
-			fmt.Printf("Warning: failed to merge slash-split file (romanized) for '%s': %v\n", page.Title, err)
+		if err := mergeSlashSplitFile(spaceDir, page, true, warn); err != nil {
+			warn("failed to merge slash-split file (romanized) for '%s': %v", page.Title, err)
 		}
 	}
 
@@ -230,7 +230,7 @@ func findPagesWithSlashInTitle(pages []*PageMeta) []*PageMeta {
 
 // mergeSlashSplitFile merges a file that was incorrectly split due to "/" in the title
 // If romanized is true, it looks for romanized filenames; otherwise, it looks for original Korean filenames
-func mergeSlashSplitFile(spaceDir string, page *PageMeta, romanized bool) error {
+func mergeSlashSplitFile(spaceDir string, page *PageMeta, romanized bool, warn WarnFunc) error {
 	// The title contains "/", which means docmost created a nested structure
 	// We need to find the incorrectly created path and merge it into a single file
 
@@ -333,7 +333,7 @@ func mergeSlashSplitFile(spaceDir string, page *PageMeta, romanized bool) error
 		// Read the content from the wrong location
 		content, err := os.ReadFile(wrongFilePath)
 		if err != nil {
-			fmt.Printf("Warning: failed to read file %s: %v\n", wrongFilePath, err)
+			warn("failed to read file %s: %v", wrongFilePath, err)
 			return nil
 		}
 
@@ -349,24 +349,24 @@ func mergeSlashSplitFile(spaceDir string, page *PageMeta, romanized bool) error
 		// Write to the correct location
 		fmt.Printf("  Merging slash-split file: %s -> %s\n", wrongFilePath, correctFilePath)
 		if err := os.WriteFile(correctFilePath, content, 0644); err != nil {
-			fmt.Printf("Warning: failed to write merged file %s: %v\n", correctFilePath, err)
+			warn("failed to write merged file %s: %v", correctFilePath, err)
 			return nil
 		}
 
 		// Remove the wrong file
 		if err := os.Remove(wrongFilePath); err != nil {
-			fmt.Printf("Warning: failed to remove wrong file %s: %v\n", wrongFilePath, err)
+			warn("failed to remove wrong file %s: %v", wrongFilePath, err)
 		}
 
 		// Try to remove the empty parent directories
-		cleanupEmptyParentDirs(path, parentDir)
+		cleanupEmptyParentDirs(path, parentDir, warn)
 
 		return filepath.SkipDir // Found and processed, skip further processing in this directory
 	})
 }
 
 // cleanupEmptyParentDirs removes empty directories up to the stopDir
-func cleanupEmptyParentDirs(dir, stopDir string) {
+func cleanupEmptyParentDirs(dir, stopDir string, warn WarnFunc) {
 	for dir != stopDir && dir != filepath.Dir(dir) {
 		entries, err := os.ReadDir(dir)
 		if err != nil {
@@ -378,7 +378,7 @@ func cleanupEmptyParentDirs(dir, stopDir string) {
 
 		// Remove empty directory
 		if err := os.Remove(dir); err != nil {
-			fmt.Printf("Warning: failed to remove empty directory %s: %v\n", dir, err)
+			warn("failed to remove empty directory %s: %v", dir, err)
 			return
 		}
 		fmt.Printf("  Removed empty directory: %s\n", dir)
@@ -391,7 +391,7 @@ func cleanupEmptyParentDirs(dir, stopDir string) {
 // RemoveSpaceBeforeExtension renames .md files that have a space before the extension.
 // For example: "OIDC .md" -> "OIDC.md"
 // This fixes issues where Docusaurus fails to load chunks for files with space before extension.
-func RemoveSpaceBeforeExtension(spaceDir string) error {
+func RemoveSpaceBeforeExtension(spaceDir string, warn WarnFunc) error {
 	// Collect all .md files that have space before extension
 	var pathsToRename []string
 
@@ -440,13 +440,13 @@ func RemoveSpaceBeforeExtension(spaceDir string) error {
 
 		// Check if new path already exists
 		if _, err := os.Stat(newPath); err == nil {
-			fmt.Printf("Warning: target file already exists, skipping: %s\n", newPath)
+			warn("target file already exists, skipping: %s", newPath)
 			continue
 		}
 
 		fmt.Printf("  Removing space before extension: %s -> %s\n", oldPath, newPath)
 		if err := os.Rename(oldPath, newPath); err != nil {
-			fmt.Printf("Warning: failed to rename %s to %s: %v\n", oldPath, newPath, err)
+			warn("failed to rename %s to %s: %v", oldPath, newPath, err)
 		}
 	}
 
@@ -503,7 +503,7 @@ func removeSpaceBeforeExt(name string) string {
 //	Expected (after this fix):
 //	  └── Security365 환경 인증-인가 관련 공통 에러 페이지.md
 //	And _metadata.json title is updated to: "Security365 환경 인증-인가 관련 공통 에러 페이지"
-func FixSlashInTitles(spaceDir string) error {
+func FixSlashInTitles(spaceDir string, warn WarnFunc) error {
 	metaPath := filepath.Join(spaceDir, "_metadata.json")
 
 	// Read metadata file
@@ -521,7 +521,7 @@ func FixSlashInTitles(spaceDir string) error {
 	modified := false
 
 	// Find all pages with "/" in their title and fix them
-	fixSlashPagesRecursive(spaceDir, spaceMeta.Pages, &modified)
+	fixSlashPagesRecursive(spaceDir, spaceMeta.Pages, &modified, warn)
 
 	// If modifications were made, save the updated metadata
 	if modified {
@@ -539,7 +539,7 @@ func FixSlashInTitles(spaceDir string) error {
 }
 
 // fixSlashPagesRecursive recursively processes pages to fix slash-split files
-func fixSlashPagesRecursive(spaceDir string, pages []*PageMeta, modified *bool) {
+func fixSlashPagesRecursive(spaceDir string, pages []*PageMeta, modified *bool, warn WarnFunc) {
 	for _, page := range pages {
 		if strings.Contains(page.Title, "/") {
 			// Calculate expected correct filename based on title
@@ -551,7 +551,7 @@ func fixSlashPagesRecursive(spaceDir string, pages []*PageMeta, modified *bool)
 			expectedFileName := strings.Join(correctFileNameParts, "-") + ".md"
 
 			// Try to fix the slash-split file structure
-			newFilePath := fixSlashSplitPage(spaceDir, page)
+			newFilePath := fixSlashSplitPage(spaceDir, page, warn)
 
 			// Update title regardless of whether file was moved
 			oldTitle := page.Title
@@ -577,21 +577,21 @@ func fixSlashPagesRecursive(spaceDir string, pages []*PageMeta, modified *bool)
 					}
 					*modified = true
 				} else {
-					fmt.Printf("  Warning: Could not find file for '%s' (expected: %s)\n", oldTitle, expectedFileName)
+					warn("could not find file for '%s' (expected: %s)", oldTitle, expectedFileName)
 				}
 			}
 		}
 
 		// Process children recursively
 		if page.HasChildren && len(page.Children) > 0 {
-			fixSlashPagesRecursive(spaceDir, page.Children, modified)
+			fixSlashPagesRecursive(spaceDir, page.Children, modified, warn)
 		}
 	}
 }
 
 // fixSlashSplitPage fixes a single page that was incorrectly split due to "/" in title
 // Returns the new file path (relative to spaceDir) if fixed, empty string otherwise
-func fixSlashSplitPage(spaceDir string, page *PageMeta) string {
+func fixSlashSplitPage(spaceDir string, page *PageMeta, warn WarnFunc) string {
 	// The title contains "/", which means docmost created a nested structure
 	titleParts := strings.Split(page.Title, "/")
 	if len(titleParts) < 2 {
@@ -652,7 +652,7 @@ func fixSlashSplitPage(spaceDir string, page *PageMeta) string {
 		// Read the content from the wrong location
 		content, err := os.ReadFile(wrongFilePath)
 		if err != nil {
-			fmt.Printf("Warning: failed to read file %s: %v\n", wrongFilePath, err)
+			warn("failed to read file %s: %v", wrongFilePath, err)
 			return nil
 		}
 
@@ -668,17 +668,17 @@ func fixSlashSplitPage(spaceDir string, page *PageMeta) string {
 		// Write to the correct location
 		fmt.Printf("  Fixing slash-split file: %s -> %s\n", wrongFilePath, correctFilePath)
 		if err := os.WriteFile(correctFilePath, content, 0644); err != nil {
-			fmt.Printf("Warning: failed to write merged file %s: %v\n", correctFilePath, err)
+			warn("failed to write merged file %s: %v", correctFilePath, err)
 			return nil
 		}
 
 		// Remove the wrong file
 		if err := os.Remove(wrongFilePath); err != nil {
-			fmt.Printf("Warning: failed to remove wrong file %s: %v\n", wrongFilePath, err)
+			warn("failed to remove wrong file %s: %v", wrongFilePath, err)
 		}
 
 		// Try to remove the empty parent directories
-		cleanupEmptyParentDirs(path, spaceDir)
+		cleanupEmptyParentDirs(path, spaceDir, warn)
 
 		// Calculate relative path from spaceDir for the new file
 		relPath, err := filepath.Rel(spaceDir, correctFilePath)
@@ -695,19 +695,20 @@ func fixSlashSplitPage(spaceDir string, page *PageMeta) string {
 
 // RemoveOrphanedFiles removes files that exist in the filesystem but are not referenced in _metadata.json.
 // This handles the case where previously deleted items still exist in the export folder.
+// It returns the "/"-separated paths of the removed files, relative to spaceDir.
 // Note: FixSlashInTitles should be called before this function.
-func RemoveOrphanedFiles(spaceDir string) error {
+func RemoveOrphanedFiles(spaceDir string, warn WarnFunc) ([]string, error) {
 	metaPath := filepath.Join(spaceDir, "_metadata.json")
 
 	// Read metadata file
 	metaData, err := os.ReadFile(metaPath)
 	if err != nil {
-		return fmt.Errorf("failed to read metadata: %w", err)
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
 	}
 
 	var spaceMeta SpaceMeta
 	if err := json.Unmarshal(metaData, &spaceMeta); err != nil {
-		return fmt.Errorf("failed to parse metadata: %w", err)
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
 	}
 
 	// Build a set of valid file paths from metadata
@@ -762,18 +763,21 @@ func RemoveOrphanedFiles(spaceDir string) error {
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	fmt.Printf("  Scanned %d .md files in filesystem\n", len(allMdFiles))
 
 	// Remove orphaned files
+	var removed []string
 	for _, filePath := range filesToRemove {
 		relPath, _ := filepath.Rel(spaceDir, filePath)
 		fmt.Printf("  Removing orphaned file: %s\n", relPath)
 		if err := os.Remove(filePath); err != nil {
-			fmt.Printf("  Warning: failed to remove orphaned file %s: %v\n", relPath, err)
+			warn("failed to remove orphaned file %s: %v", relPath, err)
+			continue
 		}
+		removed = append(removed, filepath.ToSlash(relPath))
 	}
 
 	if len(filesToRemove) > 0 {
@@ -782,7 +786,7 @@ func RemoveOrphanedFiles(spaceDir string) error {
 		fmt.Printf("  No orphaned files found\n")
 	}
 
-	return nil
+	return removed, nil
 }
 
 // collectValidFilePaths recursively collects all file paths from the metadata pages
@@ -801,7 +805,7 @@ func collectValidFilePaths(pages []*PageMeta, validFiles map[string]bool) {
 // It removes files matching these criteria:
 // 1. Filename is "untitled.md" (case-insensitive) with content "# untitled" or "# untitled (N)"
 // 2. Filename is "untitled N.md" (where N is a number, case-insensitive) with content starting with "# untitled"
-func RemoveUntitledFiles(spaceDir string) error {
+func RemoveUntitledFiles(spaceDir string, warn WarnFunc) error {
 	var filesToRemove []string
 
 	err := filepath.Walk(spaceDir, func(path string, info os.FileInfo, err error) error {
@@ -824,7 +828,7 @@ func RemoveUntitledFiles(spaceDir string) error {
 		// Read the file content
 		content, err := os.ReadFile(path)
 		if err != nil {
-			fmt.Printf("Warning: failed to read file %s: %v\n", path, err)
+			warn("failed to read file %s: %v", path, err)
 			return nil
 		}
 
@@ -855,7 +859,7 @@ func RemoveUntitledFiles(spaceDir string) error {
 	for _, filePath := range filesToRemove {
 		fmt.Printf("  Removing untitled placeholder: %s\n", filePath)
 		if err := os.Remove(filePath); err != nil {
-			fmt.Printf("Warning: failed to remove file %s: %v\n", filePath, err)
+			warn("failed to remove file %s: %v", filePath, err)
 		}
 	}
 
@@ -120,7 +120,7 @@ func TestSanitizeSpecialCharacters_Basic(t *testing.T) {
 	}
 
 	// Run sanitization
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed: %v", err)
 	}
 
@@ -176,7 +176,7 @@ func TestSanitizeSpecialCharacters_NestedDirectories(t *testing.T) {
 	}
 
 	// Run sanitization
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed: %v", err)
 	}
 
@@ -217,7 +217,7 @@ func TestSanitizeSpecialCharacters_NonMdFilesPreserved(t *testing.T) {
 	}
 
 	// Run sanitization
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed: %v", err)
 	}
 
@@ -254,7 +254,7 @@ func TestSanitizeSpecialCharacters_DirectoriesAlwaysSanitized(t *testing.T) {
 	}
 
 	// Run sanitization
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed: %v", err)
 	}
 
@@ -291,7 +291,7 @@ func TestSanitizeSpecialCharacters_NoChangesNeeded(t *testing.T) {
 	}
 
 	// Run sanitization
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed: %v", err)
 	}
 
@@ -313,7 +313,7 @@ func TestSanitizeSpecialCharacters_EmptyDirectory(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	// Run sanitization on empty directory
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed on empty dir: %v", err)
 	}
 }
@@ -339,7 +339,7 @@ func TestSanitizeSpecialCharacters_ConflictingNames(t *testing.T) {
 	}
 
 	// Run sanitization - should not overwrite existing file
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed: %v", err)
 	}
 
@@ -393,7 +393,7 @@ func TestSanitizeSpecialCharacters_RealWorldExample(t *testing.T) {
 	}
 
 	// Run sanitization
-	if err := SanitizeSpecialCharacters(tempDir); err != nil {
+	if err := SanitizeSpecialCharacters(tempDir, t.Logf); err != nil {
 		t.Fatalf("SanitizeSpecialCharacters failed: %v", err)
 	}
 
@@ -462,7 +462,7 @@ func TestMergeSlashSplitFiles(t *testing.T) {
 	}
 
 	// Run the merge function
-	if err := MergeSlashSplitFiles(tempDir); err != nil {
+	if err := MergeSlashSplitFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeSlashSplitFiles failed: %v", err)
 	}
 
@@ -540,7 +540,7 @@ func TestMergeSlashSplitFiles_MultipleSlashes(t *testing.T) {
 	}
 
 	// Run the merge function
-	if err := MergeSlashSplitFiles(tempDir); err != nil {
+	if err := MergeSlashSplitFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeSlashSplitFiles failed: %v", err)
 	}
 
@@ -602,7 +602,7 @@ func TestMergeSlashSplitFiles_NoSlashInTitle(t *testing.T) {
 	}
 
 	// Run the merge function (should do nothing)
-	if err := MergeSlashSplitFiles(tempDir); err != nil {
+	if err := MergeSlashSplitFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeSlashSplitFiles failed: %v", err)
 	}
 
@@ -671,7 +671,7 @@ func TestMergeSlashSplitFiles_NestedPages(t *testing.T) {
 	}
 
 	// Run the merge function
-	if err := MergeSlashSplitFiles(tempDir); err != nil {
+	if err := MergeSlashSplitFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeSlashSplitFiles failed: %v", err)
 	}
 
@@ -741,7 +741,7 @@ func TestMergeSlashSplitFiles_KoreanFilenames(t *testing.T) {
 	}
 
 	// Run the merge function
-	if err := MergeSlashSplitFiles(tempDir); err != nil {
+	if err := MergeSlashSplitFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("MergeSlashSplitFiles failed: %v", err)
 	}
 
@@ -848,7 +848,7 @@ func TestRemoveSpaceBeforeExtension_Basic(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveSpaceBeforeExtension(tempDir); err != nil {
+	if err := RemoveSpaceBeforeExtension(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveSpaceBeforeExtension failed: %v", err)
 	}
 
@@ -894,7 +894,7 @@ func TestRemoveSpaceBeforeExtension_NestedDirectories(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveSpaceBeforeExtension(tempDir); err != nil {
+	if err := RemoveSpaceBeforeExtension(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveSpaceBeforeExtension failed: %v", err)
 	}
 
@@ -920,7 +920,7 @@ func TestRemoveSpaceBeforeExtension_MultipleSpaces(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveSpaceBeforeExtension(tempDir); err != nil {
+	if err := RemoveSpaceBeforeExtension(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveSpaceBeforeExtension failed: %v", err)
 	}
 
@@ -946,7 +946,7 @@ func TestRemoveSpaceBeforeExtension_NoChangesNeeded(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveSpaceBeforeExtension(tempDir); err != nil {
+	if err := RemoveSpaceBeforeExtension(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveSpaceBeforeExtension failed: %v", err)
 	}
 
@@ -977,7 +977,7 @@ func TestRemoveSpaceBeforeExtension_ConflictingNames(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveSpaceBeforeExtension(tempDir); err != nil {
+	if err := RemoveSpaceBeforeExtension(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveSpaceBeforeExtension failed: %v", err)
 	}
 
@@ -1019,7 +1019,7 @@ func TestRemoveSpaceBeforeExtension_MultipleFiles(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveSpaceBeforeExtension(tempDir); err != nil {
+	if err := RemoveSpaceBeforeExtension(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveSpaceBeforeExtension failed: %v", err)
 	}
 
@@ -1053,7 +1053,7 @@ func TestRemoveSpaceBeforeExtension_NonMdFilesIgnored(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveSpaceBeforeExtension(tempDir); err != nil {
+	if err := RemoveSpaceBeforeExtension(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveSpaceBeforeExtension failed: %v", err)
 	}
 
@@ -1078,7 +1078,7 @@ func TestRemoveUntitledFiles_Basic(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1103,7 +1103,7 @@ func TestRemoveUntitledFiles_WithWhitespace(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1128,7 +1128,7 @@ func TestRemoveUntitledFiles_DifferentContent(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1153,7 +1153,7 @@ func TestRemoveUntitledFiles_DifferentFilename(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1189,7 +1189,7 @@ func TestRemoveUntitledFiles_NestedDirectories(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1225,7 +1225,7 @@ func TestRemoveUntitledFiles_CaseInsensitive(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1247,7 +1247,7 @@ func TestRemoveUntitledFiles_EmptyDirectory(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	// Run on empty directory (should not fail)
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed on empty dir: %v", err)
 	}
 }
@@ -1298,7 +1298,7 @@ func TestRemoveUntitledFiles_MixedFiles(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1341,7 +1341,7 @@ func TestRemoveUntitledFiles_NumberedUntitled(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1369,7 +1369,7 @@ func TestRemoveUntitledFiles_NumberedWithContent(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1394,7 +1394,7 @@ func TestRemoveUntitledFiles_NumberedDifferentHeading(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1444,7 +1444,7 @@ func TestRemoveUntitledFiles_NumberedMixed(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
@@ -1502,7 +1502,7 @@ func TestRemoveUntitledFiles_UntitledWithParenNumber(t *testing.T) {
 	}
 
 	// Run the function
-	if err := RemoveUntitledFiles(tempDir); err != nil {
+	if err := RemoveUntitledFiles(tempDir, t.Logf); err != nil {
 		t.Fatalf("RemoveUntitledFiles failed: %v", err)
 	}
 
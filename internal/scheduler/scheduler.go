@@ -103,8 +103,16 @@ func (s *Scheduler) runSyncSafe() {
 		s.wg.Done()
 	}()
 
+	// Bound the run by SyncTimeout; shutdown still cancels it through s.ctx
+	ctx := s.ctx
+	if s.cfg.SyncTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(s.ctx, s.cfg.SyncTimeout)
+		defer cancel()
+	}
+
 	startTime := time.Now()
-	err := s.syncFunc(s.ctx, s.cfg)
+	err := s.syncFunc(ctx, s.cfg)
 
 	s.mu.Lock()
 	s.lastSyncTime = time.Now()
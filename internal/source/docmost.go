@@ -0,0 +1,143 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jung/doc2git/internal/docmost"
+)
+
+// Docmost is a Source backed by the Docmost API
+type Docmost struct {
+	client   *docmost.Client
+	rootPage string        // ID or slug ID of the page whose subtree is exported, if set
+	root     *docmost.Page // resolved from rootPage by Collections
+}
+
+// NewDocmost creates a Docmost source. If rootPage is set, only the space containing
+// that page is listed, and only the page and its descendants are exported from it.
+func NewDocmost(client *docmost.Client, rootPage string) *Docmost {
+	return &Docmost{client: client, rootPage: rootPage}
+}
+
+// Client returns the Docmost client of the source
+func (d *Docmost) Client() *docmost.Client {
+	return d.client
+}
+
+func (d *Docmost) Kind() string {
+	return "docmost"
+}
+
+// StartRecording starts a new bundle if the client records its traffic (see docmost.WithRecorder)
+func (d *Docmost) StartRecording() error {
+	return d.client.StartRecording()
+}
+
+// Collections logs in and lists the spaces of the workspace. Until a probe of the server
+// succeeds, every call probes it, failing if its version is not supported.
+func (d *Docmost) Collections(ctx context.Context) ([]Collection, error) {
+	log.Println("Logging in to Docmost...")
+	if err := d.client.Login(ctx); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	log.Println("Login successful!")
+
+	if d.client.Capabilities() == nil {
+		caps, err := d.client.Probe(ctx)
+		if err != nil {
+			return nil, err
+		}
+		logCapabilities(caps)
+	}
+
+	spaces, err := d.client.ListSpaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	d.root = nil
+	if d.rootPage == "" {
+		return spaces, nil
+	}
+
+	// Only the space containing the root page is exported
+	root, err := d.client.GetPage(ctx, d.rootPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find root page %s: %w", d.rootPage, err)
+	}
+	d.root = root
+	log.Printf("Exporting only the subtree of page %q (%s)", root.Title, root.ID)
+
+	var matched []Collection
+	for _, space := range spaces {
+		if space.ID == root.SpaceID {
+			matched = append(matched, space)
+		}
+	}
+	return matched, nil
+}
+
+func (d *Docmost) PageTree(ctx context.Context, c Collection) (*Tree, error) {
+	if d.root != nil {
+		return d.client.GetSubtreeMetadata(ctx, c, *d.root)
+	}
+	return d.client.GetSpaceMetadata(ctx, c)
+}
+
+// FetchFiles downloads the markdown export of the space and matches its files to pages.
+// With a root page, only the files of its subtree are kept, moved to the top of dir.
+func (d *Docmost) FetchFiles(ctx context.Context, c Collection, tree *Tree, dir string) ([]string, error) {
+	log.Printf("Exporting space: %s (%s)", c.Name, c.ID)
+	files, err := d.client.DownloadSpace(ctx, c, dir)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Space '%s': %d files saved to %s", c.Name, len(files), dir)
+	for _, issue := range docmost.AssignFilePaths(tree, files) {
+		logFileIssue(c, issue)
+	}
+
+	if d.root == nil {
+		return files, nil
+	}
+	files, err = docmost.RerootExport(tree, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract subtree of page %s: %w", d.root.Title, err)
+	}
+	log.Printf("Space '%s': %d files kept from the subtree of %q", c.Name, len(files), d.root.Title)
+	return files, nil
+}
+
+// PatchFiles re-exports only the pages that changed since previous
+func (d *Docmost) PatchFiles(ctx context.Context, previous, current *Tree, dir string) ([]string, error) {
+	return d.client.PatchSpace(ctx, previous, current, dir)
+}
+
+// logCapabilities reports the detected server version and the features that are not available
+func logCapabilities(caps *docmost.Capabilities) {
+	if caps.Version != "" {
+		log.Printf("Docmost version: %s", caps.Version)
+	} else {
+		log.Printf("Warning: Docmost does not report its version; assuming it is %s or later", docmost.MinSupportedVersion)
+	}
+	if !caps.Enveloped {
+		log.Println("Docmost responses are not enveloped, decoding them as they are")
+	}
+	if !caps.PageExport {
+		log.Println("Docmost has no page export; changed spaces are always exported as a whole")
+	}
+}
+
+// logFileIssue reports a page whose exported file could not be identified unambiguously
+func logFileIssue(space Collection, issue docmost.FileIssue) {
+	switch issue.Kind {
+	case docmost.FileAmbiguous:
+		log.Printf("Warning: space '%s': page %q (%s) matches %d files %v, using %s",
+			space.Name, issue.Title, issue.PageID, len(issue.Candidates), issue.Candidates, issue.FilePath)
+	default:
+		log.Printf("Warning: space '%s': page %q (%s) has no file of its own, %v already belong to other pages",
+			space.Name, issue.Title, issue.PageID, issue.Candidates)
+	}
+}
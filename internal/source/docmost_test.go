@@ -0,0 +1,34 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/fakedocmost"
+)
+
+// TestDocmost_CollectionsRejectsOldVersionEveryRun tests that an unsupported server keeps
+// failing on later runs instead of only the first one
+func TestDocmost_CollectionsRejectsOldVersionEveryRun(t *testing.T) {
+	server, fake := fakedocmost.NewTestServer(t, "../fakedocmost/testdata", fakedocmost.WithVersion("0.5.2"))
+	client, err := docmost.NewClient(server.URL, fakedocmost.DefaultEmail, fakedocmost.DefaultPassword)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	src := NewDocmost(client, "")
+
+	for run := 1; run <= 2; run++ {
+		var versionErr *docmost.UnsupportedVersionError
+		if _, err := src.Collections(context.Background()); !errors.As(err, &versionErr) {
+			t.Fatalf("run %d: expected UnsupportedVersionError, got %v", run, err)
+		}
+	}
+	if client.Capabilities() != nil {
+		t.Errorf("capabilities of an unsupported server were kept")
+	}
+	if n := fake.Requests("/api/spaces/export"); n != 0 {
+		t.Errorf("unsupported server was exported from %d times", n)
+	}
+}
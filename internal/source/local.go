@@ -0,0 +1,289 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/fsutil"
+)
+
+// ManifestFile is the name of the page tree manifest in each collection directory
+const ManifestFile = "tree.json"
+
+// Local is a Source that reads markdown files from a directory. Every subdirectory
+// containing a tree.json manifest is a collection; its files are exported as they are,
+// in the order and hierarchy declared by the manifest.
+type Local struct {
+	dir string
+}
+
+// NewLocal creates a source reading the collections under dir
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (l *Local) Kind() string {
+	return "local"
+}
+
+// manifest is the tree.json of a collection. Every field except the page titles is optional.
+type manifest struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Slug        string          `json:"slug"`
+	Description string          `json:"description"`
+	Pages       []*manifestPage `json:"pages"`
+}
+
+// manifestPage is a page of a manifest. File is relative to the collection directory and
+// defaults to "<parent file without .md>/<title>.md", the layout of a Docmost export.
+type manifestPage struct {
+	ID       string          `json:"id"`
+	Title    string          `json:"title"`
+	File     string          `json:"file"`
+	Children []*manifestPage `json:"children"`
+}
+
+// Collections lists the subdirectories of the source directory that have a manifest,
+// sorted by directory name
+func (l *Local) Collections(ctx context.Context) ([]Collection, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source directory: %w", err)
+	}
+
+	var collections []Collection
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(l.dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err != nil {
+			continue
+		}
+		m, err := readManifest(dir)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := latestModTime(dir)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, Collection{
+			ID:          m.ID,
+			Name:        m.Name,
+			Slug:        m.Slug,
+			Description: m.Description,
+			UpdatedAt:   updated,
+		})
+	}
+	return collections, nil
+}
+
+// PageTree builds the page tree of a collection from its manifest.
+// Page timestamps are the modification times of their files, so incremental sync
+// notices edited pages.
+func (l *Local) PageTree(ctx context.Context, c Collection) (*Tree, error) {
+	dir, m, err := l.find(c)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{
+		ID:          m.ID,
+		Name:        m.Name,
+		Slug:        m.Slug,
+		Description: m.Description,
+		CreatedAt:   c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   c.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	seen := make(map[string]bool)
+	tree.Pages, err = buildPages(dir, m.Pages, nil, "", seen, &tree.TotalPages)
+	if err != nil {
+		return nil, fmt.Errorf("collection %s: %w", c.Name, err)
+	}
+	return tree, nil
+}
+
+// FetchFiles copies the files of the collection into dir, except for the manifest,
+// hidden files and symbolic links
+func (l *Local) FetchFiles(ctx context.Context, c Collection, tree *Tree, dir string) ([]string, error) {
+	src, _, err := l.find(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// A link could copy any file of the host into the output
+		if d.IsDir() || rel == ManifestFile || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		if err := fsutil.CopyFile(p, filepath.Join(dir, rel)); err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy collection %s: %w", c.Name, err)
+	}
+	return files, nil
+}
+
+// find returns the directory and manifest of a listed collection
+func (l *Local) find(c Collection) (string, *manifest, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read source directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(l.dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, ManifestFile)); err != nil {
+			continue
+		}
+		m, err := readManifest(dir)
+		if err != nil {
+			return "", nil, err
+		}
+		if m.ID == c.ID {
+			return dir, m, nil
+		}
+	}
+	return "", nil, fmt.Errorf("collection %s (%s) not found in %s", c.Name, c.ID, l.dir)
+}
+
+// readManifest reads the manifest of the collection in dir and fills in defaults
+// derived from the directory name
+func readManifest(dir string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", filepath.Join(dir, ManifestFile), err)
+	}
+
+	base := filepath.Base(dir)
+	if m.Name == "" {
+		m.Name = base
+	}
+	if m.Slug == "" {
+		m.Slug = base
+	}
+	if m.ID == "" {
+		m.ID = m.Slug
+	}
+	return &m, nil
+}
+
+// buildPages converts manifest pages to page metadata. parentDir is the directory of the
+// parent page's children in the Docmost layout ("" at the top level).
+func buildPages(dir string, pages []*manifestPage, parentID *string, parentDir string, seen map[string]bool, total *int) ([]*docmost.PageMeta, error) {
+	metas := make([]*docmost.PageMeta, 0, len(pages))
+	for i, p := range pages {
+		if strings.TrimSpace(p.Title) == "" {
+			return nil, fmt.Errorf("page %d under %q has no title", i+1, parentDir)
+		}
+
+		file := p.File
+		if file == "" {
+			file = path.Join(parentDir, p.Title+".md")
+		}
+		file = path.Clean(filepath.ToSlash(file))
+		if path.IsAbs(file) || file == ".." || strings.HasPrefix(file, "../") {
+			return nil, fmt.Errorf("page %q: file %s is outside the collection", p.Title, p.File)
+		}
+		if seen[file] {
+			return nil, fmt.Errorf("page %q: file %s belongs to another page", p.Title, file)
+		}
+		seen[file] = true
+
+		info, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, fmt.Errorf("page %q: %w", p.Title, err)
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return nil, fmt.Errorf("page %q: file %s is a symbolic link", p.Title, file)
+		}
+
+		id := p.ID
+		if id == "" {
+			id = file
+		}
+		meta := &docmost.PageMeta{
+			ID:           id,
+			SlugID:       id,
+			Title:        p.Title,
+			Position:     fmt.Sprintf("a%04d", i),
+			ParentPageID: parentID,
+			HasChildren:  len(p.Children) > 0,
+			FilePath:     file,
+			UpdatedAt:    info.ModTime().UTC().Format(time.RFC3339Nano),
+		}
+		*total++
+
+		meta.Children, err = buildPages(dir, p.Children, &meta.ID, strings.TrimSuffix(file, ".md"), seen, total)
+		if err != nil {
+			return nil, err
+		}
+		if len(meta.Children) == 0 {
+			meta.Children = nil
+		}
+		metas = append(metas, meta)
+	}
+	return metas, nil
+}
+
+// latestModTime returns the most recent modification time of the files and directories
+// in dir, so that any edit, including added or deleted attachments, marks the collection as changed.
+// Symbolic links are skipped like in FetchFiles.
+func latestModTime(dir string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest.UTC(), err
+}
@@ -0,0 +1,154 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// TestLocalPageTree tests reading the collections and page tree of a local directory source
+func TestLocalPageTree(t *testing.T) {
+	src := NewLocal("testdata")
+
+	collections, err := src.Collections(context.Background())
+	if err != nil {
+		t.Fatalf("Collections failed: %v", err)
+	}
+	if len(collections) != 1 || collections[0].Name != "Handbook" || collections[0].ID != "handbook" {
+		t.Fatalf("expected only the handbook collection, got %+v", collections)
+	}
+
+	tree, err := src.PageTree(context.Background(), collections[0])
+	if err != nil {
+		t.Fatalf("PageTree failed: %v", err)
+	}
+	if tree.TotalPages != 4 || len(tree.Pages) != 2 {
+		t.Fatalf("unexpected tree: %d pages, %d top-level", tree.TotalPages, len(tree.Pages))
+	}
+
+	onboarding := tree.Pages[0]
+	if onboarding.FilePath != "Onboarding.md" || len(onboarding.Children) != 2 {
+		t.Fatalf("unexpected onboarding page: %+v", onboarding)
+	}
+	if got := onboarding.Children[0].FilePath; got != "Onboarding/First Week.md" {
+		t.Errorf("expected default file path Onboarding/First Week.md, got %s", got)
+	}
+	if got := onboarding.Children[1].ParentPageID; got == nil || *got != onboarding.ID {
+		t.Errorf("child page does not point to its parent")
+	}
+	if tree.Pages[1].Position <= onboarding.Position {
+		t.Errorf("pages are not positioned in manifest order")
+	}
+	if tree.Pages[1].UpdatedAt == "" {
+		t.Errorf("page has no updatedAt")
+	}
+}
+
+// TestLocalFetchFiles tests copying the pages and attachments of a local collection
+func TestLocalFetchFiles(t *testing.T) {
+	src := NewLocal("testdata")
+	collections, _ := src.Collections(context.Background())
+	tree, err := src.PageTree(context.Background(), collections[0])
+	if err != nil {
+		t.Fatalf("PageTree failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	files, err := src.FetchFiles(context.Background(), collections[0], tree, dir)
+	if err != nil {
+		t.Fatalf("FetchFiles failed: %v", err)
+	}
+
+	sort.Strings(files)
+	want := []string{"Onboarding.md", "Onboarding/First Week.md", "Onboarding/accounts.md", "benefits.md", "files/team.png"}
+	if len(files) != len(want) {
+		t.Fatalf("expected files %v, got %v", want, files)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("expected files %v, got %v", want, files)
+			break
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ManifestFile)); !os.IsNotExist(err) {
+		t.Errorf("manifest was copied into the export")
+	}
+}
+
+// TestLocalSkipsSymlinks tests that symbolic links in a collection are never followed,
+// so they cannot copy files from outside the collection into the output
+func TestLocalSkipsSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "space")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte(`{"pages": [{"title": "Page", "file": "page.md"}]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "page.md"), []byte("# Page\n"), 0644)
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "secret.txt")); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+	os.Symlink(outside, filepath.Join(dir, "linked"))
+
+	src := NewLocal(root)
+	collections, err := src.Collections(context.Background())
+	if err != nil {
+		t.Fatalf("Collections failed: %v", err)
+	}
+	tree, err := src.PageTree(context.Background(), collections[0])
+	if err != nil {
+		t.Fatalf("PageTree failed: %v", err)
+	}
+
+	out := t.TempDir()
+	files, err := src.FetchFiles(context.Background(), collections[0], tree, out)
+	if err != nil {
+		t.Fatalf("FetchFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "page.md" {
+		t.Errorf("expected only page.md, got %v", files)
+	}
+	for _, name := range []string{"secret.txt", filepath.Join("linked", "secret.txt")} {
+		if _, err := os.Lstat(filepath.Join(out, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was copied through a symbolic link", name)
+		}
+	}
+
+	// A page file that is a link is rejected
+	os.WriteFile(filepath.Join(dir, ManifestFile), []byte(`{"pages": [{"title": "Secret", "file": "secret.txt"}]}`), 0644)
+	if _, err := src.PageTree(context.Background(), collections[0]); err == nil {
+		t.Errorf("expected a page file that is a symbolic link to be rejected")
+	}
+}
+
+// TestLocalRejectsInvalidManifest tests that invalid tree.json manifests are rejected
+func TestLocalRejectsInvalidManifest(t *testing.T) {
+	tests := map[string]string{
+		"escaping file":   `{"pages": [{"title": "Secret", "file": "../secret.md"}]}`,
+		"missing file":    `{"pages": [{"title": "Missing"}]}`,
+		"missing title":   `{"pages": [{"file": "page.md"}]}`,
+		"file used twice": `{"pages": [{"title": "Page", "file": "page.md"}, {"title": "Copy", "file": "page.md"}]}`,
+	}
+
+	for name, manifest := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "space")
+			os.MkdirAll(dir, 0755)
+			os.WriteFile(filepath.Join(dir, ManifestFile), []byte(manifest), 0644)
+			os.WriteFile(filepath.Join(dir, "page.md"), []byte("# Page\n"), 0644)
+
+			src := NewLocal(root)
+			collections, err := src.Collections(context.Background())
+			if err != nil {
+				t.Fatalf("Collections failed: %v", err)
+			}
+			if _, err := src.PageTree(context.Background(), collections[0]); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
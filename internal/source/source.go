@@ -0,0 +1,48 @@
+// Package source abstracts where exported content comes from. A Source lists collections
+// (Docmost spaces), builds the page tree of a collection and writes its files into a
+// directory, so the same post-processing and Docusaurus output can be produced from
+// Docmost or from other wikis.
+package source
+
+import (
+	"context"
+
+	"github.com/jung/doc2git/internal/docmost"
+)
+
+// Collection is a top-level group of pages, such as a Docmost space
+type Collection = docmost.Space
+
+// Tree is the page tree of a collection. It is written as _metadata.json, which
+// post-processing reads to name and order the exported files.
+type Tree = docmost.SpaceMeta
+
+// Source provides the content of a sync run
+type Source interface {
+	// Kind names the source type in logs, e.g. "docmost"
+	Kind() string
+
+	// Collections lists the collections available for export
+	Collections(ctx context.Context) ([]Collection, error)
+
+	// PageTree returns the page tree of a collection
+	PageTree(ctx context.Context, c Collection) (*Tree, error)
+
+	// FetchFiles writes the files of a collection into dir, which already exists, and
+	// sets the FilePath of every page in tree. It returns the written file paths relative to dir.
+	FetchFiles(ctx context.Context, c Collection, tree *Tree, dir string) ([]string, error)
+}
+
+// Patcher is implemented by sources that can bring a previous FetchFiles result in dir
+// up to date by fetching only the pages that changed between previous and current.
+// It returns docmost.ErrStructureChanged if the whole collection has to be fetched again.
+type Patcher interface {
+	PatchFiles(ctx context.Context, previous, current *Tree, dir string) ([]string, error)
+}
+
+// Recorder is implemented by sources that can record their traffic for replaying it
+// later. StartRecording is called once at the start of every sync run, before the first
+// request, so each run is recorded on its own; other commands never record.
+type Recorder interface {
+	StartRecording() error
+}
@@ -0,0 +1,135 @@
+package syncstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/jung/doc2git/internal/docmost"
+	"github.com/jung/doc2git/internal/fsutil"
+)
+
+// RawDir returns the directory holding the unprocessed export of a space.
+// It is the base that page-level exports are patched into on the next sync.
+func (s *Store) RawDir(spaceID string) string {
+	return filepath.Join(s.dir, "raw", spaceID)
+}
+
+// LoadRaw copies the unprocessed export saved for a space into dst and returns its metadata.
+// It returns nil without error if no export was saved yet. The files are copied rather
+// than linked because post-processing rewrites them in place.
+func (s *Store) LoadRaw(spaceID, dst string) (*docmost.SpaceMeta, error) {
+	src := s.RawDir(spaceID)
+	data, err := os.ReadFile(filepath.Join(src, "_metadata.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved export metadata: %w", err)
+	}
+
+	var meta docmost.SpaceMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse saved export metadata: %w", err)
+	}
+
+	if err := copyDir(src, dst, nil); err != nil {
+		return nil, fmt.Errorf("failed to copy saved export: %w", err)
+	}
+	return &meta, nil
+}
+
+// SaveRaw replaces the unprocessed export saved for a space with a copy of src,
+// which must contain the _metadata.json describing it.
+// The copy is made next to the saved export and renamed into place, so a failed
+// save leaves the previous export intact.
+func (s *Store) SaveRaw(spaceID, src string) error {
+	return s.saveRaw(spaceID, src, nil)
+}
+
+// SavePatchedRaw is SaveRaw for a src that was loaded with LoadRaw and then patched.
+// Only the files in changed ("/"-separated and relative to src) and _metadata.json are
+// copied; the others are hardlinked from the saved export, which is never modified in place.
+func (s *Store) SavePatchedRaw(spaceID, src string, changed []string) error {
+	keep := make(map[string]bool)
+	if err := collectFiles(src, keep); err != nil {
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+	delete(keep, "_metadata.json")
+	for _, name := range changed {
+		delete(keep, name)
+	}
+	return s.saveRaw(spaceID, src, keep)
+}
+
+// saveRaw saves src as the export of a space, hardlinking the files in keep from the
+// previously saved export
+func (s *Store) saveRaw(spaceID, src string, keep map[string]bool) error {
+	dst := s.RawDir(spaceID)
+	tmp := dst + "_temp"
+	old := dst + "_old"
+
+	os.RemoveAll(tmp)
+	os.RemoveAll(old)
+	if err := copyDir(src, tmp, func(rel string) bool {
+		return keep[rel] && os.Link(filepath.Join(dst, filepath.FromSlash(rel)), filepath.Join(tmp, filepath.FromSlash(rel))) == nil
+	}); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+
+	if err := os.Rename(dst, old); err != nil && !os.IsNotExist(err) {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Rename(old, dst)
+		os.RemoveAll(tmp)
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+	os.RemoveAll(old)
+	return nil
+}
+
+// collectFiles adds the "/"-separated paths of the regular files under dir to files
+func collectFiles(dir string, files map[string]bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+}
+
+// copyDir copies the regular files and directories under src to dst. If link is set, it
+// is tried first for every file and reports whether it linked the file into dst instead.
+func copyDir(src, dst string, link func(rel string) bool) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if link != nil && link(filepath.ToSlash(rel)) {
+			return nil
+		}
+		return fsutil.CopyFile(path, target)
+	})
+}
@@ -0,0 +1,65 @@
+package syncstate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSavePatchedRaw tests that saving a patched export copies only the changed files
+// and links the unchanged ones from the saved export
+func TestSavePatchedRaw(t *testing.T) {
+	store := NewStore(t.TempDir())
+	export := t.TempDir()
+	for name, content := range map[string]string{
+		"_metadata.json": `{"id":"space-1","pages":[]}`,
+		"a.md":           "a",
+		"docs/b.md":      "b",
+		"c.md":           "c",
+	} {
+		path := filepath.Join(export, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SaveRaw("space-1", export); err != nil {
+		t.Fatalf("SaveRaw failed: %v", err)
+	}
+	raw := store.RawDir("space-1")
+	savedA, err := os.Stat(filepath.Join(raw, "a.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	work := filepath.Join(t.TempDir(), "work")
+	if meta, err := store.LoadRaw("space-1", work); err != nil || meta == nil {
+		t.Fatalf("LoadRaw failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(work, "docs", "b.md"), []byte("b2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(work, "c.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SavePatchedRaw("space-1", work, []string{"docs/b.md"}); err != nil {
+		t.Fatalf("SavePatchedRaw failed: %v", err)
+	}
+
+	if info, err := os.Stat(filepath.Join(raw, "a.md")); err != nil || !os.SameFile(info, savedA) {
+		t.Errorf("unchanged a.md was not linked from the saved export")
+	}
+	if data, err := os.ReadFile(filepath.Join(raw, "docs", "b.md")); err != nil || string(data) != "b2" {
+		t.Errorf("changed docs/b.md = %q, %v; want b2", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(raw, "c.md")); !os.IsNotExist(err) {
+		t.Errorf("removed c.md is still in the saved export")
+	}
+
+	// The working copy does not share files with the saved export
+	if info, err := os.Stat(filepath.Join(work, "a.md")); err != nil || os.SameFile(info, savedA) {
+		t.Errorf("LoadRaw linked a.md instead of copying it")
+	}
+}
@@ -0,0 +1,264 @@
+package syncstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jung/doc2git/internal/docmost"
+)
+
+// SpaceState records the page tree of a space as of its last successful sync
+type SpaceState struct {
+	SpaceID   string               `json:"spaceId"`
+	SpaceName string               `json:"spaceName"`
+	UpdatedAt string               `json:"updatedAt"`
+	RootPage  string               `json:"rootPageId,omitempty"`
+	Pages     map[string]PageState `json:"pages"` // keyed by page ID
+	SyncedAt  time.Time            `json:"syncedAt"`
+}
+
+// PageState records the fields of a page that affect the exported output
+type PageState struct {
+	Title        string `json:"title"`
+	Position     string `json:"position"`
+	ParentPageID string `json:"parentPageId,omitempty"`
+	UpdatedAt    string `json:"updatedAt,omitempty"`
+}
+
+// FromMetadata builds the state of a space from its metadata
+func FromMetadata(meta *docmost.SpaceMeta) *SpaceState {
+	state := &SpaceState{
+		SpaceID:   meta.ID,
+		SpaceName: meta.Name,
+		UpdatedAt: meta.UpdatedAt,
+		RootPage:  meta.RootPageID,
+		Pages:     make(map[string]PageState),
+	}
+
+	var collect func(pages []*docmost.PageMeta, parentID string)
+	collect = func(pages []*docmost.PageMeta, parentID string) {
+		for _, p := range pages {
+			state.Pages[p.ID] = PageState{
+				Title:        p.Title,
+				Position:     p.Position,
+				ParentPageID: parentID,
+				UpdatedAt:    p.UpdatedAt,
+			}
+			collect(p.Children, p.ID)
+		}
+	}
+	collect(meta.Pages, "")
+
+	return state
+}
+
+// Unchanged reports whether s describes the same content as previous.
+// A page without an updatedAt timestamp cannot be proven unchanged, so its presence
+// always counts as a change.
+func (s *SpaceState) Unchanged(previous *SpaceState) bool {
+	if previous == nil || s.SpaceID != previous.SpaceID || s.SpaceName != previous.SpaceName || s.UpdatedAt != previous.UpdatedAt || s.RootPage != previous.RootPage {
+		return false
+	}
+	if len(s.Pages) != len(previous.Pages) {
+		return false
+	}
+	for id, page := range s.Pages {
+		if page.UpdatedAt == "" {
+			return false
+		}
+		if prev, ok := previous.Pages[id]; !ok || prev != page {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingTimestamps returns the number of pages without an updatedAt timestamp.
+// While it is not zero, the space is synced in full on every run.
+func (s *SpaceState) MissingTimestamps() int {
+	missing := 0
+	for _, page := range s.Pages {
+		if page.UpdatedAt == "" {
+			missing++
+		}
+	}
+	return missing
+}
+
+// Changes lists how the pages of a space changed between two syncs. A page can be
+// both moved and retitled; pages that were added or removed appear in no other list.
+type Changes struct {
+	Added     []PageRef     `json:"added"`
+	Removed   []PageRef     `json:"removed"`
+	Moved     []PageMove    `json:"moved"`     // now below a different parent page
+	Reordered []PageReorder `json:"reordered"` // same parent page, different position among its siblings
+	Retitled  []PageRetitle `json:"retitled"`  // title changed
+	Updated   []PageRef     `json:"updated"`   // content changed according to updatedAt
+}
+
+// PageRef identifies a page in Changes
+type PageRef struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// PageMove is a page whose parent changed; an empty parent is the top level of the space
+type PageMove struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	FromParent string `json:"fromParentPageId"`
+	ToParent   string `json:"toParentPageId"`
+}
+
+// PageReorder is a page whose position among its siblings changed. Positions are the
+// fractional index keys of Docmost, which sort in sibling order.
+type PageReorder struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	FromPosition string `json:"fromPosition"`
+	ToPosition   string `json:"toPosition"`
+}
+
+// PageRetitle is a page whose title changed
+type PageRetitle struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Changes compares s with the state of the previous sync. Without a previous state
+// every page counts as added. Each list is sorted by title, then ID.
+func (s *SpaceState) Changes(previous *SpaceState) *Changes {
+	c := &Changes{
+		Added:     []PageRef{},
+		Removed:   []PageRef{},
+		Moved:     []PageMove{},
+		Reordered: []PageReorder{},
+		Retitled:  []PageRetitle{},
+		Updated:   []PageRef{},
+	}
+	var before map[string]PageState
+	if previous != nil {
+		before = previous.Pages
+	}
+
+	for id, page := range s.Pages {
+		prev, ok := before[id]
+		if !ok {
+			c.Added = append(c.Added, PageRef{ID: id, Title: page.Title})
+			continue
+		}
+		if prev.ParentPageID != page.ParentPageID {
+			c.Moved = append(c.Moved, PageMove{ID: id, Title: page.Title, FromParent: prev.ParentPageID, ToParent: page.ParentPageID})
+		} else if prev.Position != page.Position {
+			c.Reordered = append(c.Reordered, PageReorder{ID: id, Title: page.Title, FromPosition: prev.Position, ToPosition: page.Position})
+		}
+		if prev.Title != page.Title {
+			c.Retitled = append(c.Retitled, PageRetitle{ID: id, From: prev.Title, To: page.Title})
+		}
+		if prev.UpdatedAt != page.UpdatedAt || page.UpdatedAt == "" {
+			c.Updated = append(c.Updated, PageRef{ID: id, Title: page.Title})
+		}
+	}
+	for id, page := range before {
+		if _, ok := s.Pages[id]; !ok {
+			c.Removed = append(c.Removed, PageRef{ID: id, Title: page.Title})
+		}
+	}
+
+	sortRefs(c.Added)
+	sortRefs(c.Removed)
+	sortRefs(c.Updated)
+	sort.Slice(c.Moved, func(i, j int) bool {
+		return lessPage(c.Moved[i].Title, c.Moved[i].ID, c.Moved[j].Title, c.Moved[j].ID)
+	})
+	sort.Slice(c.Reordered, func(i, j int) bool {
+		return lessPage(c.Reordered[i].Title, c.Reordered[i].ID, c.Reordered[j].Title, c.Reordered[j].ID)
+	})
+	sort.Slice(c.Retitled, func(i, j int) bool {
+		return lessPage(c.Retitled[i].To, c.Retitled[i].ID, c.Retitled[j].To, c.Retitled[j].ID)
+	})
+	return c
+}
+
+func sortRefs(refs []PageRef) {
+	sort.Slice(refs, func(i, j int) bool { return lessPage(refs[i].Title, refs[i].ID, refs[j].Title, refs[j].ID) })
+}
+
+func lessPage(titleA, idA, titleB, idB string) bool {
+	if titleA != titleB {
+		return titleA < titleB
+	}
+	return idA < idB
+}
+
+// Store persists SpaceState files in a directory, one file per space
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store that keeps its files in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// path returns the state file path for a space
+func (s *Store) path(spaceID string) string {
+	return filepath.Join(s.dir, spaceID+".json")
+}
+
+// Load reads the state of a space. It returns nil without error if no state was saved yet.
+func (s *Store) Load(spaceID string) (*SpaceState, error) {
+	data, err := os.ReadFile(s.path(spaceID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	var state SpaceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return &state, nil
+}
+
+// Save writes the state of a space, replacing any previous state.
+// The file is written to a temporary name first so that a crash never leaves a partial state behind.
+func (s *Store) Save(state *SpaceState) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync state: %w", err)
+	}
+
+	path := s.path(state.SpaceID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the state and the saved raw export of a space
+func (s *Store) Remove(spaceID string) error {
+	if err := os.Remove(s.path(spaceID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sync state: %w", err)
+	}
+	if err := os.RemoveAll(s.RawDir(spaceID)); err != nil {
+		return fmt.Errorf("failed to remove saved export: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package syncstate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jung/doc2git/internal/docmost"
+)
+
+func testMetadata() *docmost.SpaceMeta {
+	return &docmost.SpaceMeta{
+		ID:        "space-1",
+		Name:      "Space",
+		UpdatedAt: "2024-01-01T00:00:00Z",
+		Pages: []*docmost.PageMeta{
+			{
+				ID:        "a",
+				Title:     "A",
+				Position:  "a0",
+				UpdatedAt: "2024-01-01T00:00:00Z",
+				Children: []*docmost.PageMeta{
+					{ID: "b", Title: "B", Position: "a0", UpdatedAt: "2024-01-02T00:00:00Z"},
+				},
+			},
+		},
+	}
+}
+
+// TestUnchanged tests which differences between two page trees count as changes
+func TestUnchanged(t *testing.T) {
+	previous := FromMetadata(testMetadata())
+
+	tests := []struct {
+		name   string
+		modify func(meta *docmost.SpaceMeta)
+		want   bool
+	}{
+		{"identical", func(meta *docmost.SpaceMeta) {}, true},
+		{"page updated", func(meta *docmost.SpaceMeta) { meta.Pages[0].Children[0].UpdatedAt = "2024-02-01T00:00:00Z" }, false},
+		{"page renamed", func(meta *docmost.SpaceMeta) { meta.Pages[0].Title = "A2" }, false},
+		{"page moved", func(meta *docmost.SpaceMeta) {
+			meta.Pages = append(meta.Pages, meta.Pages[0].Children[0])
+			meta.Pages[0].Children = nil
+		}, false},
+		{"page added", func(meta *docmost.SpaceMeta) {
+			meta.Pages = append(meta.Pages, &docmost.PageMeta{ID: "c", Title: "C", UpdatedAt: "2024-01-01T00:00:00Z"})
+		}, false},
+		{"page deleted", func(meta *docmost.SpaceMeta) { meta.Pages[0].Children = nil }, false},
+		{"space updated", func(meta *docmost.SpaceMeta) { meta.UpdatedAt = "2024-03-01T00:00:00Z" }, false},
+		{"missing timestamp", func(meta *docmost.SpaceMeta) { meta.Pages[0].UpdatedAt = "" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := testMetadata()
+			tt.modify(meta)
+			if got := FromMetadata(meta).Unchanged(previous); got != tt.want {
+				t.Errorf("Unchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if FromMetadata(testMetadata()).Unchanged(nil) {
+		t.Error("Unchanged(nil) = true, want false")
+	}
+}
+
+// TestUnchanged_WithoutTimestamps tests that a tree without updatedAt timestamps,
+// as listed by servers that leave them out, is never taken as unchanged
+func TestUnchanged_WithoutTimestamps(t *testing.T) {
+	meta := testMetadata()
+	meta.Pages[0].UpdatedAt = ""
+	meta.Pages[0].Children[0].UpdatedAt = ""
+
+	previous := FromMetadata(meta)
+	current := FromMetadata(meta)
+	if current.Unchanged(previous) {
+		t.Errorf("tree without timestamps reported as unchanged")
+	}
+	if n := current.MissingTimestamps(); n != 2 {
+		t.Errorf("MissingTimestamps() = %d, want 2", n)
+	}
+	if n := FromMetadata(testMetadata()).MissingTimestamps(); n != 0 {
+		t.Errorf("MissingTimestamps() = %d for a tree with timestamps, want 0", n)
+	}
+}
+
+// TestChanges tests the added, moved, reordered, retitled and updated pages between two states
+func TestChanges(t *testing.T) {
+	previous := FromMetadata(testMetadata())
+
+	meta := testMetadata()
+	b := meta.Pages[0].Children[0]
+	b.Title = "B2"
+	meta.Pages[0].Children = nil
+	meta.Pages = append(meta.Pages, b, &docmost.PageMeta{ID: "c", Title: "C", UpdatedAt: "2024-01-01T00:00:00Z"})
+	meta.Pages[0].UpdatedAt = "2024-02-01T00:00:00Z"
+	meta.Pages[0].Position = "a1"
+
+	changes := FromMetadata(meta).Changes(previous)
+	if len(changes.Added) != 1 || changes.Added[0].ID != "c" {
+		t.Errorf("added = %v, want page c", changes.Added)
+	}
+	if len(changes.Removed) != 0 {
+		t.Errorf("removed = %v, want none", changes.Removed)
+	}
+	if want := (PageMove{ID: "b", Title: "B2", FromParent: "a", ToParent: ""}); len(changes.Moved) != 1 || changes.Moved[0] != want {
+		t.Errorf("moved = %v, want %v", changes.Moved, want)
+	}
+	if want := (PageReorder{ID: "a", Title: "A", FromPosition: "a0", ToPosition: "a1"}); len(changes.Reordered) != 1 || changes.Reordered[0] != want {
+		t.Errorf("reordered = %v, want %v", changes.Reordered, want)
+	}
+	if want := (PageRetitle{ID: "b", From: "B", To: "B2"}); len(changes.Retitled) != 1 || changes.Retitled[0] != want {
+		t.Errorf("retitled = %v, want %v", changes.Retitled, want)
+	}
+	if len(changes.Updated) != 1 || changes.Updated[0].ID != "a" {
+		t.Errorf("updated = %v, want page a", changes.Updated)
+	}
+
+	same := previous.Changes(previous)
+	if n := len(same.Added) + len(same.Removed) + len(same.Moved) + len(same.Reordered) + len(same.Retitled) + len(same.Updated); n != 0 {
+		t.Errorf("identical states reported %d changes", n)
+	}
+	if changes := previous.Changes(nil); len(changes.Added) != 2 {
+		t.Errorf("without a previous state expected 2 added pages, got %v", changes.Added)
+	}
+}
+
+// TestStoreSaveLoad tests saving and loading the sync state of a space
+func TestStoreSaveLoad(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	loaded, err := store.Load("space-1")
+	if err != nil || loaded != nil {
+		t.Fatalf("Load() before Save = %v, %v; want nil, nil", loaded, err)
+	}
+
+	state := FromMetadata(testMetadata())
+	state.SyncedAt = time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	if err := store.Save(state); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err = store.Load("space-1")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !loaded.Unchanged(state) || !loaded.SyncedAt.Equal(state.SyncedAt) {
+		t.Errorf("Load() = %+v, want %+v", loaded, state)
+	}
+}